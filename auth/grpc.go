@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bigtable_api/logging"
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type principalContextKey struct{}
+
+// UnaryInterceptor and StreamInterceptor authenticate gRPC calls like
+// Middleware, from the authorization or x-api-key metadata.
+func UnaryInterceptor(keys *KeyStore, tokens *TokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateCall(ctx, keys, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamInterceptor(keys *KeyStore, tokens *TokenVerifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateCall(stream.Context(), keys, tokens)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// PrincipalFromContext returns the principal of a gRPC call, or nil when the
+// call went through no authentication interceptor.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+func authenticateCall(ctx context.Context, keys *KeyStore, tokens *TokenVerifier) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token, apiKey string
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		apiKey = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, credential, _ := strings.Cut(values[0], " ")
+		switch {
+		case strings.EqualFold(scheme, "Bearer"):
+			token = strings.TrimSpace(credential)
+		case strings.EqualFold(scheme, "ApiKey"):
+			apiKey = strings.TrimSpace(credential)
+		}
+	}
+	principal, err := authenticate(ctx, token, apiKey, keys, tokens)
+	if err != nil {
+		logging.FromContext(ctx).Warn("error authenticating call", "error", err)
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ctx = logging.With(ctx, "principal", principal.ID)
+	return context.WithValue(ctx, principalContextKey{}, principal), nil
+}
+
+// principalStream carries the context of an authenticated stream.
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
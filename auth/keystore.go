@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const hashPrefix = "sha256:"
+
+// reloadInterval limits how often the key file is checked for changes.
+const reloadInterval = 5 * time.Second
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+type apiKey struct {
+	ID        string     `json:"id"`
+	Hash      string     `json:"hash"`
+	Tier      string     `json:"tier"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Scopes    Scopes     `json:"scopes"`
+}
+
+type keyFile struct {
+	Keys []apiKey `json:"keys"`
+}
+
+// KeyStore holds the API keys read from a local JSON file. Only the SHA-256 of
+// each key is stored. The file is read again whenever it changes, so keys can
+// be added, rotated or revoked without restarting the service.
+type KeyStore struct {
+	path      string
+	mu        sync.RWMutex
+	keys      map[string]apiKey
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewKeyStore(path string) (*KeyStore, error) {
+	store := &KeyStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// HashKey returns the value to be stored in the key file for a raw key.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+func (s *KeyStore) Reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	keys := make(map[string]apiKey, len(file.Keys))
+	for _, key := range file.Keys {
+		if !strings.HasPrefix(key.Hash, hashPrefix) {
+			return errors.New("key " + key.ID + " has no sha256 hash")
+		}
+		keys[strings.ToLower(key.Hash)] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.modTime = info.ModTime()
+	s.checkedAt = time.Now()
+	return nil
+}
+
+func (s *KeyStore) reloadIfChanged() {
+	s.mu.RLock()
+	stale := time.Since(s.checkedAt) > reloadInterval
+	modTime := s.modTime
+	s.mu.RUnlock()
+	if !stale {
+		return
+	}
+
+	info, err := os.Stat(s.path)
+	if err == nil && info.ModTime().Equal(modTime) {
+		s.mu.Lock()
+		s.checkedAt = time.Now()
+		s.mu.Unlock()
+		return
+	}
+	// keeps serving the previous keys if the new file is broken
+	if err := s.Reload(); err != nil {
+		slog.Error("error reloading api keys", "path", s.path, "error", err)
+		s.mu.Lock()
+		s.checkedAt = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+// Authenticate returns the principal owning the raw key.
+func (s *KeyStore) Authenticate(key string) (*Principal, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	stored, ok := s.keys[HashKey(key)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	if stored.ExpiresAt != nil && time.Now().After(*stored.ExpiresAt) {
+		return nil, ErrInvalidKey
+	}
+	return &Principal{ID: stored.ID, Tier: stored.Tier, Scopes: stored.Scopes}, nil
+}
@@ -0,0 +1,78 @@
+package auth_test
+
+import (
+	"bigtable_api/auth"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyStoreSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestKeyStoreSuite(t *testing.T) {
+	suite.Run(t, new(KeyStoreSuite))
+}
+
+func (k *KeyStoreSuite) SetupTest() {
+	k.path = filepath.Join(k.T().TempDir(), "keys.json")
+	k.writeKeys(`{"keys": [{"id": "dashboard", "hash": "` + auth.HashKey("secret") + `", "scopes": {"datatypes": ["w"], "areas": ["A3277*"]}}]}`)
+}
+
+func (k *KeyStoreSuite) writeKeys(content string) {
+	err := os.WriteFile(k.path, []byte(content), 0600)
+	k.Nil(err)
+}
+
+func (k *KeyStoreSuite) TestAuthenticate() {
+	store, err := auth.NewKeyStore(k.path)
+	k.Nil(err)
+
+	principal, err := store.Authenticate("secret")
+	k.Nil(err)
+	k.Equal("dashboard", principal.ID)
+
+	_, err = store.Authenticate("wrong")
+	k.ErrorIs(err, auth.ErrInvalidKey)
+
+	_, err = store.Authenticate("")
+	k.ErrorIs(err, auth.ErrInvalidKey)
+}
+
+func (k *KeyStoreSuite) TestScopes() {
+	store, err := auth.NewKeyStore(k.path)
+	k.Nil(err)
+	principal, err := store.Authenticate("secret")
+	k.Nil(err)
+
+	k.True(principal.AllowsOperation(auth.OperationRead))
+	k.False(principal.AllowsOperation(auth.OperationWrite))
+	k.Nil(principal.Authorize("w", []string{"A327734", "A327735"}))
+	k.NotNil(principal.Authorize("f", []string{"A327734"}))
+	k.NotNil(principal.Authorize("w", []string{"A327734", "A100000"}))
+	k.NotNil(principal.Authorize("w", nil))
+}
+
+func (k *KeyStoreSuite) TestRotation() {
+	store, err := auth.NewKeyStore(k.path)
+	k.Nil(err)
+
+	expired := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	k.writeKeys(`{"keys": [
+		{"id": "old", "hash": "` + auth.HashKey("secret") + `", "expires_at": "` + expired + `"},
+		{"id": "new", "hash": "` + auth.HashKey("rotated") + `"}
+	]}`)
+	k.Nil(store.Reload())
+
+	_, err = store.Authenticate("secret")
+	k.ErrorIs(err, auth.ErrInvalidKey)
+
+	principal, err := store.Authenticate("rotated")
+	k.Nil(err)
+	k.Equal("new", principal.ID)
+}
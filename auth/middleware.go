@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"bigtable_api/logging"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Middleware authenticates requests carrying either an API key, in the
+// X-API-Key header or as "Authorization: ApiKey <key>", or an OIDC bearer
+// token. Any of keys and tokens may be nil to disable that method.
+func Middleware(keys *KeyStore, tokens *TokenVerifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := authenticate(ctx, bearerToken(ctx.Request), apiKeyFromRequest(ctx.Request), keys, tokens)
+		if err != nil {
+			logging.FromContext(ctx).Warn("error authenticating request", "error", err)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "failed", "error": err.Error()})
+			return
+		}
+		ctx.Set(principalKey, principal)
+		ctx.Request = ctx.Request.WithContext(logging.With(ctx.Request.Context(), "principal", principal.ID))
+		ctx.Next()
+	}
+}
+
+// authenticate checks the bearer token when there is one, or else the API key.
+func authenticate(ctx context.Context, token, apiKey string, keys *KeyStore, tokens *TokenVerifier) (*Principal, error) {
+	if token != "" {
+		if tokens == nil {
+			return nil, ErrInvalidToken
+		}
+		return tokens.Authenticate(ctx, token)
+	}
+	if keys == nil {
+		return nil, errors.New("missing bearer token")
+	}
+	return keys.Authenticate(apiKey)
+}
+
+// Require refuses requests whose principal lacks the operation.
+func Require(op Operation) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal := PrincipalFrom(ctx)
+		if principal != nil && !principal.AllowsOperation(op) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": "operation " + string(op) + " is not allowed"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// PrincipalFrom returns the authenticated principal, or nil when the request
+// went through no authentication middleware.
+func PrincipalFrom(ctx *gin.Context) *Principal {
+	value, ok := ctx.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*Principal)
+	return principal
+}
+
+func apiKeyFromRequest(req *http.Request) string {
+	if key := req.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, key, found := strings.Cut(req.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+func bearerToken(req *http.Request) string {
+	scheme, token, found := strings.Cut(req.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
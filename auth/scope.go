@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"path"
+)
+
+type Operation string
+
+const (
+	OperationRead  Operation = "read"
+	OperationWrite Operation = "write"
+	OperationAdmin Operation = "admin"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Scopes limit what a principal can reach. An empty list means no restriction
+// for that dimension, except for Operations, which defaults to read only.
+type Scopes struct {
+	Datatypes  []string    `json:"datatypes"`
+	Operations []Operation `json:"operations"`
+	Areas      []string    `json:"areas"`
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string
+	Tier   string
+	Scopes Scopes
+}
+
+func (p *Principal) AllowsOperation(op Operation) bool {
+	if len(p.Scopes.Operations) == 0 {
+		return op == OperationRead
+	}
+	for _, allowed := range p.Scopes.Operations {
+		// admin implies every other operation
+		if allowed == op || allowed == OperationAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) AllowsDatatype(dataType string) bool {
+	if len(p.Scopes.Datatypes) == 0 {
+		return true
+	}
+	for _, allowed := range p.Scopes.Datatypes {
+		if allowed == dataType {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsArea matches the area ID against the scope patterns, which use the
+// path.Match syntax (e.g. "A3277*").
+func (p *Principal) AllowsArea(areaID string) bool {
+	if len(p.Scopes.Areas) == 0 {
+		return true
+	}
+	for _, pattern := range p.Scopes.Areas {
+		if ok, err := path.Match(pattern, areaID); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictsAreas reports whether the principal can only reach some areas, in
+// which case scans that are not bound to an area must be refused.
+func (p *Principal) RestrictsAreas() bool {
+	for _, pattern := range p.Scopes.Areas {
+		if pattern == "*" {
+			return false
+		}
+	}
+	return len(p.Scopes.Areas) > 0
+}
+
+// Authorize checks that the principal can query the datatype and every area.
+// An empty area list stands for a scan over all the areas of the datatype.
+func (p *Principal) Authorize(dataType string, areas []string) error {
+	if p == nil {
+		return nil
+	}
+	if !p.AllowsDatatype(dataType) {
+		return errors.New("datatype " + dataType + " is not allowed")
+	}
+	if len(areas) == 0 && p.RestrictsAreas() {
+		return errors.New("area_id is required")
+	}
+	for _, area := range areas {
+		if !p.AllowsArea(area) {
+			return errors.New("area " + area + " is not allowed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// Tenant is what an organization owns: usually the list of its area IDs.
+type Tenant struct {
+	Tier   string `json:"tier"`
+	Scopes Scopes `json:"scopes"`
+}
+
+type TokenConfig struct {
+	Issuer   string
+	Audience string
+	// TenantClaim is the claim holding the organization of the user.
+	TenantClaim string
+	// TenantsFile maps each organization to its Tenant.
+	TenantsFile string
+}
+
+// TokenVerifier validates the JWTs issued to front-end users by the OIDC
+// provider and maps their organization to a Principal.
+type TokenVerifier struct {
+	jwks    *JWKS
+	config  TokenConfig
+	tenants map[string]Tenant
+	parser  *jwt.Parser
+}
+
+func NewTokenVerifier(jwks *JWKS, config TokenConfig) (*TokenVerifier, error) {
+	if config.TenantClaim == "" {
+		config.TenantClaim = "org_id"
+	}
+	data, err := os.ReadFile(config.TenantsFile)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tenants map[string]Tenant `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &TokenVerifier{
+		jwks:    jwks,
+		config:  config,
+		tenants: file.Tenants,
+		parser:  jwt.NewParser(options...),
+	}, nil
+}
+
+// Authenticate checks the token signature and claims and returns the
+// principal of its organization. Users of unknown organizations are refused.
+func (v *TokenVerifier) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.jwks.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	tenantID, _ := claims[v.config.TenantClaim].(string)
+	tenant, ok := v.tenants[tenantID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown organization %q", ErrInvalidToken, tenantID)
+	}
+	// an organization without areas would otherwise reach every area
+	if len(tenant.Scopes.Areas) == 0 {
+		return nil, fmt.Errorf("%w: organization %q owns no areas", ErrInvalidToken, tenantID)
+	}
+
+	subject, _ := claims.GetSubject()
+	return &Principal{ID: tenantID + "/" + subject, Tier: tenant.Tier, Scopes: tenant.Scopes}, nil
+}
@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: climate/v1/climate.proto
+
+package climatepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ClimateService_ReadClimateData_FullMethodName = "/bigtableapi.climate.v1.ClimateService/ReadClimateData"
+	ClimateService_Latest_FullMethodName          = "/bigtableapi.climate.v1.ClimateService/Latest"
+	ClimateService_Write_FullMethodName           = "/bigtableapi.climate.v1.ClimateService/Write"
+)
+
+// ClimateServiceClient is the client API for ClimateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ClimateServiceClient interface {
+	// ReadClimateData streams the cells of a read, with the parameters of
+	// GET /read/climate-data.
+	ReadClimateData(ctx context.Context, in *ReadClimateDataRequest, opts ...grpc.CallOption) (ClimateService_ReadClimateDataClient, error)
+	// Latest returns the latest version of the most recent key of an area up to
+	// now, or NOT_FOUND when the area has no key in the last year.
+	Latest(ctx context.Context, in *LatestRequest, opts ...grpc.CallOption) (*Cell, error)
+	// Write stores the cells sent by the client, answering once the stream is
+	// closed.
+	Write(ctx context.Context, opts ...grpc.CallOption) (ClimateService_WriteClient, error)
+}
+
+type climateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClimateServiceClient(cc grpc.ClientConnInterface) ClimateServiceClient {
+	return &climateServiceClient{cc}
+}
+
+func (c *climateServiceClient) ReadClimateData(ctx context.Context, in *ReadClimateDataRequest, opts ...grpc.CallOption) (ClimateService_ReadClimateDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ClimateService_ServiceDesc.Streams[0], ClimateService_ReadClimateData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &climateServiceReadClimateDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClimateService_ReadClimateDataClient interface {
+	Recv() (*Cell, error)
+	grpc.ClientStream
+}
+
+type climateServiceReadClimateDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *climateServiceReadClimateDataClient) Recv() (*Cell, error) {
+	m := new(Cell)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *climateServiceClient) Latest(ctx context.Context, in *LatestRequest, opts ...grpc.CallOption) (*Cell, error) {
+	out := new(Cell)
+	err := c.cc.Invoke(ctx, ClimateService_Latest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *climateServiceClient) Write(ctx context.Context, opts ...grpc.CallOption) (ClimateService_WriteClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ClimateService_ServiceDesc.Streams[1], ClimateService_Write_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &climateServiceWriteClient{stream}
+	return x, nil
+}
+
+type ClimateService_WriteClient interface {
+	Send(*WriteRequest) error
+	CloseAndRecv() (*WriteResponse, error)
+	grpc.ClientStream
+}
+
+type climateServiceWriteClient struct {
+	grpc.ClientStream
+}
+
+func (x *climateServiceWriteClient) Send(m *WriteRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *climateServiceWriteClient) CloseAndRecv() (*WriteResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(WriteResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClimateServiceServer is the server API for ClimateService service.
+// All implementations must embed UnimplementedClimateServiceServer
+// for forward compatibility
+type ClimateServiceServer interface {
+	// ReadClimateData streams the cells of a read, with the parameters of
+	// GET /read/climate-data.
+	ReadClimateData(*ReadClimateDataRequest, ClimateService_ReadClimateDataServer) error
+	// Latest returns the latest version of the most recent key of an area up to
+	// now, or NOT_FOUND when the area has no key in the last year.
+	Latest(context.Context, *LatestRequest) (*Cell, error)
+	// Write stores the cells sent by the client, answering once the stream is
+	// closed.
+	Write(ClimateService_WriteServer) error
+	mustEmbedUnimplementedClimateServiceServer()
+}
+
+// UnimplementedClimateServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedClimateServiceServer struct {
+}
+
+func (UnimplementedClimateServiceServer) ReadClimateData(*ReadClimateDataRequest, ClimateService_ReadClimateDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadClimateData not implemented")
+}
+func (UnimplementedClimateServiceServer) Latest(context.Context, *LatestRequest) (*Cell, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Latest not implemented")
+}
+func (UnimplementedClimateServiceServer) Write(ClimateService_WriteServer) error {
+	return status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedClimateServiceServer) mustEmbedUnimplementedClimateServiceServer() {}
+
+// UnsafeClimateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClimateServiceServer will
+// result in compilation errors.
+type UnsafeClimateServiceServer interface {
+	mustEmbedUnimplementedClimateServiceServer()
+}
+
+func RegisterClimateServiceServer(s grpc.ServiceRegistrar, srv ClimateServiceServer) {
+	s.RegisterService(&ClimateService_ServiceDesc, srv)
+}
+
+func _ClimateService_ReadClimateData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadClimateDataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClimateServiceServer).ReadClimateData(m, &climateServiceReadClimateDataServer{stream})
+}
+
+type ClimateService_ReadClimateDataServer interface {
+	Send(*Cell) error
+	grpc.ServerStream
+}
+
+type climateServiceReadClimateDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *climateServiceReadClimateDataServer) Send(m *Cell) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ClimateService_Latest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClimateServiceServer).Latest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClimateService_Latest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClimateServiceServer).Latest(ctx, req.(*LatestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClimateService_Write_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ClimateServiceServer).Write(&climateServiceWriteServer{stream})
+}
+
+type ClimateService_WriteServer interface {
+	SendAndClose(*WriteResponse) error
+	Recv() (*WriteRequest, error)
+	grpc.ServerStream
+}
+
+type climateServiceWriteServer struct {
+	grpc.ServerStream
+}
+
+func (x *climateServiceWriteServer) SendAndClose(m *WriteResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *climateServiceWriteServer) Recv() (*WriteRequest, error) {
+	m := new(WriteRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClimateService_ServiceDesc is the grpc.ServiceDesc for ClimateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClimateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bigtableapi.climate.v1.ClimateService",
+	HandlerType: (*ClimateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Latest",
+			Handler:    _ClimateService_Latest_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadClimateData",
+			Handler:       _ClimateService_ReadClimateData_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Write",
+			Handler:       _ClimateService_Write_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "climate/v1/climate.proto",
+}
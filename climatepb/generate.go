@@ -0,0 +1,5 @@
+// Package climatepb holds the protobuf messages and the gRPC service generated
+// from proto/climate/v1/climate.proto.
+package climatepb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=bigtable_api --go-grpc_out=.. --go-grpc_opt=module=bigtable_api climate/v1/climate.proto
@@ -2,7 +2,7 @@ package database
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"sync"
 
@@ -18,17 +18,17 @@ func GetAdminClientSingleton(ctx context.Context) (*bigtable.AdminClient, error)
 		lock.Lock()
 		defer lock.Unlock()
 		if admInstance == nil {
-			log.Println("Creating bigtable admin instance")
+			slog.Debug("Creating bigtable admin instance")
 			var err error
 			admInstance, err = bigtable.NewAdminClient(ctx, os.Getenv("PROJECT_ID"), os.Getenv("_INSTANCE_ID"))
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			log.Println("Admin instance connected!")
+			slog.Debug("Admin instance connected!")
 		}
 	} else {
-		log.Println("Admin instance connected!")
+		slog.Debug("Admin instance connected!")
 	}
 	return admInstance, nil
 }
@@ -38,17 +38,17 @@ func GetClientSingleton(ctx context.Context) (*bigtable.Client, error) {
 		lock.Lock()
 		defer lock.Unlock()
 		if clientInstance == nil {
-			log.Println("Creating bigtable client instance")
+			slog.Debug("Creating bigtable client instance")
 			var err error
 			clientInstance, err = bigtable.NewClient(ctx, os.Getenv("PROJECT_ID"), os.Getenv("_INSTANCE_ID"))
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			log.Println("Client instance connected!")
+			slog.Debug("Client instance connected!")
 		}
 	} else {
-		log.Println("Client instance connected!")
+		slog.Debug("Client instance connected!")
 	}
 	return clientInstance, nil
 }
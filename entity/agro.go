@@ -0,0 +1,26 @@
+package entity
+
+// AgroSeries is an agronomic index of an area, day by day and accumulated
+// since the first day.
+type AgroSeries struct {
+	AreaID string `json:"area_id"`
+	// Index names the index, such as gdd or utah_chill_units, and Units its
+	// units.
+	Index string    `json:"index"`
+	Units string    `json:"units"`
+	Days  []AgroDay `json:"days"`
+	Total float64   `json:"total"`
+}
+
+// AgroDay is the index of a local day. Days without readings are left out.
+type AgroDay struct {
+	Date string `json:"date"`
+	// Hours is the number of hourly readings of the day.
+	Hours      int     `json:"hours"`
+	Value      float64 `json:"value"`
+	Cumulative float64 `json:"cumulative"`
+	// TemperatureMin and TemperatureMax are the extremes of the day, for the
+	// indices computed from them.
+	TemperatureMin *float64 `json:"temperature_min,omitempty"`
+	TemperatureMax *float64 `json:"temperature_max,omitempty"`
+}
@@ -5,5 +5,18 @@ import "time"
 type BigtableOutput struct {
 	Key     string    `json:"key"`
 	Created time.Time `json:"created"`
-	Value   string    `json:"value"`
+	// Timestamp is the date of the key in the timezone requested by the
+	// client, when there is one.
+	Timestamp string `json:"timestamp,omitempty"`
+	// LeadTimeHours is the time between the creation of a forecast and the
+	// date of its key, returned when forecasts are selected by issuance.
+	LeadTimeHours *float64 `json:"lead_time_hours,omitempty"`
+	Value         string   `json:"value"`
+}
+
+// BigtableInput is a cell to be written. A zero Created is the time of the write.
+type BigtableInput struct {
+	Key     string
+	Created time.Time
+	Value   string
 }
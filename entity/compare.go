@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// Comparison lines up the forecast and the observation of an area at the same
+// key date.
+type Comparison struct {
+	AreaID string `json:"area_id"`
+	// Date is the date part of the keys, and Timestamp the same date in the
+	// timezone requested by the client.
+	Date            string                  `json:"date"`
+	Timestamp       string                  `json:"timestamp"`
+	ForecastCreated time.Time               `json:"forecast_created"`
+	ObservedCreated time.Time               `json:"observed_created"`
+	Variables       map[string]VariablePair `json:"variables"`
+}
+
+// VariablePair holds the forecast and observed values of a variable, null when
+// missing from a payload, and the forecast minus the observed value.
+type VariablePair struct {
+	Forecast   *float64 `json:"forecast"`
+	Observed   *float64 `json:"observed"`
+	Difference *float64 `json:"difference"`
+}
+
+// NewVariablePair pairs the variable of two payloads.
+func NewVariablePair(name string, forecast, observed Payload) VariablePair {
+	var pair VariablePair
+	if value, ok := forecast.Variables[name]; ok {
+		pair.Forecast = &value
+	}
+	if value, ok := observed.Variables[name]; ok {
+		pair.Observed = &value
+	}
+	if pair.Forecast != nil && pair.Observed != nil {
+		difference := *pair.Forecast - *pair.Observed
+		pair.Difference = &difference
+	}
+	return pair
+}
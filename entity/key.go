@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// KeyDateLayout is the layout of the date part of the row keys.
+const KeyDateLayout = "2006-01-02 15:04:05"
+
+// KeyLocation is the timezone of the dates in the row keys.
+var KeyLocation = time.UTC
+
+// Key is a row key of the climate_data table: datatype/area ID/date.
+type Key struct {
+	DataType string
+	AreaID   string
+	Date     time.Time
+}
+
+func ParseKey(key string) (Key, error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 {
+		return Key{}, errors.New("malformed key " + key)
+	}
+	date, err := ParseKeyDate(parts[2])
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{DataType: parts[0], AreaID: parts[1], Date: date}, nil
+}
+
+func (k Key) String() string {
+	return k.DataType + "/" + k.AreaID + "/" + FormatKeyDate(k.Date)
+}
+
+func ParseKeyDate(date string) (time.Time, error) {
+	return time.ParseInLocation(KeyDateLayout, date, KeyLocation)
+}
+
+// FormatKeyDate formats t as the date part of a row key.
+func FormatKeyDate(t time.Time) string {
+	return t.In(KeyLocation).Format(KeyDateLayout)
+}
@@ -0,0 +1,128 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// WeatherVariables are the variables of the weather payload, in the order
+// they are written by the ingestion.
+var WeatherVariables = []string{
+	"temperatureInst", "temperatureMin", "temperatureMax",
+	"humidityInst", "humidityMin", "humidityMax",
+	"atmosphericPressureInst", "atmosphericPressureMin", "atmosphericPressureMax",
+	"solarIrradianceInst", "solarIrradianceMin", "solarIrradianceMax", "solarIrradiation",
+	"rain",
+	"windSpeedInst", "windDirectionInst", "windSpeedGust", "windDirectionGust",
+}
+
+// Payload is the decoded value of a cell, such as
+// {"lonlat":[-47.77,-19.16],"weatherData":{"temperatureInst":34.15,...}}.
+type Payload struct {
+	// LonLat is the station position, when the payload has one.
+	LonLat []float64
+	// Variables holds every numeric field of the payload data objects.
+	Variables map[string]float64
+}
+
+func DecodePayload(value string) (Payload, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return Payload{}, err
+	}
+
+	payload := Payload{Variables: make(map[string]float64)}
+	for name, raw := range fields {
+		if name == "lonlat" {
+			if err := json.Unmarshal(raw, &payload.LonLat); err != nil {
+				return Payload{}, err
+			}
+			if len(payload.LonLat) != 2 {
+				return Payload{}, errors.New("lonlat must have two coordinates")
+			}
+			continue
+		}
+		// data objects, as weatherData, hold the variables
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err == nil {
+			for variable, value := range data {
+				if number, ok := value.(float64); ok {
+					payload.Variables[variable] = number
+				}
+			}
+			continue
+		}
+		var number float64
+		if err := json.Unmarshal(raw, &number); err == nil {
+			payload.Variables[name] = number
+		}
+	}
+	return payload, nil
+}
+
+// Lon and Lat return the station position, and false when it is unknown.
+func (p Payload) Lon() (float64, bool) {
+	if len(p.LonLat) != 2 {
+		return 0, false
+	}
+	return p.LonLat[0], true
+}
+
+func (p Payload) Lat() (float64, bool) {
+	if len(p.LonLat) != 2 {
+		return 0, false
+	}
+	return p.LonLat[1], true
+}
+
+// VariableNames returns the variables of the payload, the weather ones first
+// in their usual order and the others sorted.
+func (p Payload) VariableNames() []string {
+	names := make([]string, 0, len(p.Variables))
+	known := make(map[string]bool, len(WeatherVariables))
+	for _, name := range WeatherVariables {
+		known[name] = true
+		if _, ok := p.Variables[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var others []string
+	for name := range p.Variables {
+		if !known[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// ProjectPayload keeps only the given variables in the data objects of a
+// payload, leaving its other fields, such as lonlat, as they are.
+func ProjectPayload(value string, variables []string) (string, error) {
+	keep := make(map[string]bool, len(variables))
+	for _, name := range variables {
+		keep[name] = true
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", err
+	}
+	for name, raw := range fields {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &data); err == nil {
+			for variable := range data {
+				if !keep[variable] {
+					delete(data, variable)
+				}
+			}
+			if fields[name], err = json.Marshal(data); err != nil {
+				return "", err
+			}
+		} else if name != "lonlat" && !keep[name] {
+			delete(fields, name)
+		}
+	}
+	projected, err := json.Marshal(fields)
+	return string(projected), err
+}
@@ -0,0 +1,32 @@
+package entity
+
+// Skill holds the verification statistics of the forecasts of a variable in
+// an area, for the forecasts issued between LeadTimeFrom (inclusive) and
+// LeadTimeTo (exclusive) hours before their date.
+type Skill struct {
+	AreaID       string `json:"area_id"`
+	Variable     string `json:"variable"`
+	LeadTimeFrom int    `json:"lead_time_from_hours"`
+	LeadTimeTo   int    `json:"lead_time_to_hours"`
+	// Count is the number of forecasts paired with an observation.
+	Count int `json:"count"`
+	// MAE is the mean absolute error, RMSE the root mean square error and
+	// Bias the mean of the forecast minus the observed value.
+	MAE  float64 `json:"mae"`
+	RMSE float64 `json:"rmse"`
+	Bias float64 `json:"bias"`
+	// RainOccurrence is the contingency table of rain, above a threshold, for
+	// the rain variable.
+	RainOccurrence *Contingency `json:"rain_occurrence,omitempty"`
+}
+
+// Contingency counts the forecasts of an event by outcome.
+type Contingency struct {
+	Hits             int `json:"hits"`
+	Misses           int `json:"misses"`
+	FalseAlarms      int `json:"false_alarms"`
+	CorrectNegatives int `json:"correct_negatives"`
+	// HitRate is the share of the observed events that were forecast, null
+	// when none was observed.
+	HitRate *float64 `json:"hit_rate"`
+}
@@ -0,0 +1,47 @@
+package entity
+
+// VariableInfo describes a payload variable following the CF conventions.
+type VariableInfo struct {
+	LongName     string
+	Units        string
+	StandardName string
+	// CellMethods tells how the value was computed over the hour, such as
+	// "time: maximum".
+	CellMethods string
+}
+
+// variableInfo covers the weather variables. Irradiance is in W m-2, and
+// irradiation the energy of the hour in W h m-2.
+var variableInfo = map[string]VariableInfo{
+	"temperatureInst":         {"air temperature", "degC", "air_temperature", "time: point"},
+	"temperatureMin":          {"minimum air temperature", "degC", "air_temperature", "time: minimum"},
+	"temperatureMax":          {"maximum air temperature", "degC", "air_temperature", "time: maximum"},
+	"humidityInst":            {"relative humidity", "%", "relative_humidity", "time: point"},
+	"humidityMin":             {"minimum relative humidity", "%", "relative_humidity", "time: minimum"},
+	"humidityMax":             {"maximum relative humidity", "%", "relative_humidity", "time: maximum"},
+	"atmosphericPressureInst": {"atmospheric pressure", "hPa", "air_pressure", "time: point"},
+	"atmosphericPressureMin":  {"minimum atmospheric pressure", "hPa", "air_pressure", "time: minimum"},
+	"atmosphericPressureMax":  {"maximum atmospheric pressure", "hPa", "air_pressure", "time: maximum"},
+	"solarIrradianceInst":     {"solar irradiance", "W m-2", "surface_downwelling_shortwave_flux_in_air", "time: point"},
+	"solarIrradianceMin":      {"minimum solar irradiance", "W m-2", "surface_downwelling_shortwave_flux_in_air", "time: minimum"},
+	"solarIrradianceMax":      {"maximum solar irradiance", "W m-2", "surface_downwelling_shortwave_flux_in_air", "time: maximum"},
+	"solarIrradiation":        {"solar irradiation", "W h m-2", "integral_wrt_time_of_surface_downwelling_shortwave_flux_in_air", "time: sum"},
+	"rain":                    {"precipitation", "mm", "lwe_thickness_of_precipitation_amount", "time: sum"},
+	"windSpeedInst":           {"wind speed", "m s-1", "wind_speed", "time: point"},
+	"windDirectionInst":       {"wind direction", "degree", "wind_from_direction", "time: point"},
+	"windSpeedGust":           {"wind gust speed", "m s-1", "wind_speed_of_gust", "time: maximum"},
+	"windDirectionGust":       {"wind gust direction", "degree", "wind_from_direction", "time: point"},
+	// derived from the instantaneous variables, the felt temperatures have
+	// no standard name
+	"dewPoint":  {"dew point temperature", "degC", "dew_point_temperature", "time: point"},
+	"vpd":       {"vapour pressure deficit", "kPa", "water_vapor_saturation_deficit_in_air", "time: point"},
+	"heatIndex": {"heat index", "degC", "", "time: point"},
+	"windChill": {"wind chill", "degC", "", "time: point"},
+}
+
+// Variable returns the description of a payload variable, and false for the
+// variables that are not known.
+func Variable(name string) (VariableInfo, bool) {
+	info, ok := variableInfo[name]
+	return info, ok
+}
@@ -0,0 +1,27 @@
+package entity
+
+// WaterBalance is the soil water balance of an area, day by day, with the
+// totals of its days.
+type WaterBalance struct {
+	AreaID string `json:"area_id"`
+	// Capacity is the water, in mm, the soil holds.
+	Capacity float64           `json:"capacity"`
+	Days     []WaterBalanceDay `json:"days"`
+	Rain     float64           `json:"rain"`
+	ET0      float64           `json:"et0"`
+	Drainage float64           `json:"drainage"`
+}
+
+// WaterBalanceDay is the water, in mm, of a local day: the rain and the
+// reference evapotranspiration of the day, the water stored at its end, the
+// water drained above the capacity and the water missing to reach it. ET0 is
+// null when the day misses hours of one of its inputs.
+type WaterBalanceDay struct {
+	Date     string   `json:"date"`
+	Hours    int      `json:"hours"`
+	Rain     float64  `json:"rain"`
+	ET0      *float64 `json:"et0"`
+	Storage  float64  `json:"storage"`
+	Drainage float64  `json:"drainage"`
+	Deficit  float64  `json:"deficit"`
+}
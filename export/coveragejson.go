@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bigtable_api/entity"
+	"sort"
+	"time"
+)
+
+// CoverageJSONType is the media type of CoverageJSON documents.
+const CoverageJSONType = "application/prs.coverage+json"
+
+// standardNames is the vocabulary of the CF standard names.
+const standardNames = "http://vocab.nerc.ac.uk/standard_name/"
+
+// Coverage is a CoverageJSON document, as described in https://covjson.org/spec/.
+type Coverage struct {
+	Type       string               `json:"type"`
+	Domain     Domain               `json:"domain"`
+	Parameters map[string]Parameter `json:"parameters"`
+	Ranges     map[string]NdArray   `json:"ranges"`
+}
+
+type Domain struct {
+	Type        string          `json:"type"`
+	DomainType  string          `json:"domainType"`
+	Axes        map[string]Axis `json:"axes"`
+	Referencing []Reference     `json:"referencing"`
+}
+
+type Axis struct {
+	Values []interface{} `json:"values"`
+}
+
+type Reference struct {
+	Coordinates []string        `json:"coordinates"`
+	System      ReferenceSystem `json:"system"`
+}
+
+type ReferenceSystem struct {
+	Type     string `json:"type"`
+	ID       string `json:"id,omitempty"`
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// Parameter describes a variable, and is also used by the EDR collection
+// metadata.
+type Parameter struct {
+	Type             string            `json:"type"`
+	Description      map[string]string `json:"description,omitempty"`
+	Unit             *Unit             `json:"unit,omitempty"`
+	ObservedProperty ObservedProperty  `json:"observedProperty"`
+}
+
+type Unit struct {
+	Symbol string `json:"symbol"`
+}
+
+type ObservedProperty struct {
+	ID    string            `json:"id,omitempty"`
+	Label map[string]string `json:"label"`
+}
+
+// NdArray holds the values of a parameter along the time axis, nil when missing.
+type NdArray struct {
+	Type      string     `json:"type"`
+	DataType  string     `json:"dataType"`
+	AxisNames []string   `json:"axisNames"`
+	Shape     []int      `json:"shape"`
+	Values    []*float64 `json:"values"`
+}
+
+// ParameterOf describes a payload variable from its CF metadata.
+func ParameterOf(name string) Parameter {
+	parameter := Parameter{
+		Type:             "Parameter",
+		ObservedProperty: ObservedProperty{Label: map[string]string{"en": name}},
+	}
+	if info, ok := entity.Variable(name); ok {
+		parameter.Description = map[string]string{"en": info.LongName}
+		parameter.Unit = &Unit{Symbol: info.Units}
+		if info.StandardName != "" {
+			parameter.ObservedProperty.ID = standardNames + info.StandardName + "/"
+		}
+	}
+	return parameter
+}
+
+// PointSeries builds the coverage of a station from its cells. Only the latest
+// version of each key is used, and only the given parameters when there are any.
+func PointSeries(lon, lat float64, cells []entity.BigtableOutput, parameters []string) Coverage {
+	wanted := make(map[string]bool, len(parameters))
+	for _, name := range parameters {
+		wanted[name] = true
+	}
+
+	// cells come sorted by key, the latest version first
+	var times []time.Time
+	var payloads []entity.Payload
+	variables := make(map[string]float64)
+	for i, cell := range cells {
+		if i > 0 && cell.Key == cells[i-1].Key {
+			continue
+		}
+		key, err := entity.ParseKey(cell.Key)
+		if err != nil {
+			continue
+		}
+		payload, err := entity.DecodePayload(cell.Value)
+		if err != nil {
+			continue
+		}
+		times = append(times, key.Date)
+		payloads = append(payloads, payload)
+		for name := range payload.Variables {
+			if len(wanted) == 0 || wanted[name] {
+				variables[name] = 0
+			}
+		}
+	}
+	order := make([]int, len(times))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return times[order[i]].Before(times[order[j]]) })
+
+	t := make([]interface{}, len(order))
+	for i, index := range order {
+		t[i] = times[index].UTC().Format(time.RFC3339)
+	}
+	coverage := Coverage{
+		Type: "Coverage",
+		Domain: Domain{
+			Type:       "Domain",
+			DomainType: "PointSeries",
+			Axes: map[string]Axis{
+				"x": {Values: []interface{}{lon}},
+				"y": {Values: []interface{}{lat}},
+				"t": {Values: t},
+			},
+			Referencing: []Reference{
+				{Coordinates: []string{"x", "y"}, System: ReferenceSystem{Type: "GeographicCRS", ID: "http://www.opengis.net/def/crs/OGC/1.3/CRS84"}},
+				{Coordinates: []string{"t"}, System: ReferenceSystem{Type: "TemporalRS", Calendar: "Gregorian"}},
+			},
+		},
+		Parameters: make(map[string]Parameter),
+		Ranges:     make(map[string]NdArray),
+	}
+	for _, name := range (entity.Payload{Variables: variables}).VariableNames() {
+		values := make([]*float64, len(order))
+		for i, index := range order {
+			if value, ok := payloads[index].Variables[name]; ok {
+				values[i] = &value
+			}
+		}
+		coverage.Parameters[name] = ParameterOf(name)
+		coverage.Ranges[name] = NdArray{
+			Type:      "NdArray",
+			DataType:  "float",
+			AxisNames: []string{"t"},
+			Shape:     []int{len(values)},
+			Values:    values,
+		}
+	}
+	return coverage
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"bigtable_api/entity"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyColumns are written before the variables of the payload.
+var keyColumns = []string{"datatype", "area_id", "timestamp", "created", "lon", "lat"}
+
+type CSVOptions struct {
+	// Delimiter separates the fields, ',' by default.
+	Delimiter rune
+	// DecimalSeparator is written in numbers instead of '.', such as ',' for
+	// spreadsheets in pt-BR.
+	DecimalSeparator rune
+	// Location is the timezone of the timestamp and created columns.
+	Location *time.Location
+	// Variables are the columns written after the key ones,
+	// entity.WeatherVariables by default. The other variables of the payloads
+	// are left out.
+	Variables []string
+}
+
+// CSVWriter flattens cells into CSV rows, one column per variable. The
+// columns are known before the first cell, so that rows can be streamed with
+// the same header whatever variables they hold.
+type CSVWriter struct {
+	writer        *csv.Writer
+	options       CSVOptions
+	headerWritten bool
+}
+
+func NewCSVWriter(w io.Writer, options CSVOptions) *CSVWriter {
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if options.DecimalSeparator == 0 {
+		options.DecimalSeparator = '.'
+	}
+	if options.Location == nil {
+		options.Location = entity.KeyLocation
+	}
+	if options.Variables == nil {
+		options.Variables = entity.WeatherVariables
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = options.Delimiter
+	return &CSVWriter{writer: writer, options: options}
+}
+
+func (c *CSVWriter) Write(output entity.BigtableOutput) error {
+	// a cell that is not a payload still gets its key columns
+	payload, _ := entity.DecodePayload(output.Value)
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	record := make([]string, 0, len(keyColumns)+len(c.options.Variables))
+	key, err := entity.ParseKey(output.Key)
+	if err != nil {
+		record = append(record, "", "", "")
+	} else {
+		record = append(record, key.DataType, key.AreaID, key.Date.In(c.options.Location).Format(time.RFC3339))
+	}
+	record = append(record, output.Created.In(c.options.Location).Format(time.RFC3339Nano))
+
+	lon, ok := payload.Lon()
+	record = append(record, c.number(lon, ok))
+	lat, ok := payload.Lat()
+	record = append(record, c.number(lat, ok))
+	for _, column := range c.options.Variables {
+		value, ok := payload.Variables[column]
+		record = append(record, c.number(value, ok))
+	}
+	return c.writer.Write(record)
+}
+
+// Flush writes the buffered rows.
+func (c *CSVWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// Close flushes the rows, writing the header alone when there was no row.
+func (c *CSVWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// writeHeader writes the names of the columns before the first row.
+func (c *CSVWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.writer.Write(append(append([]string{}, keyColumns...), c.options.Variables...))
+}
+
+func (c *CSVWriter) number(value float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if c.options.DecimalSeparator != '.' {
+		formatted = strings.Replace(formatted, ".", string(c.options.DecimalSeparator), 1)
+	}
+	return formatted
+}
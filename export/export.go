@@ -0,0 +1,14 @@
+// Package export encodes climate cells into files for download, one row per
+// cell with a column per payload variable.
+package export
+
+import "bigtable_api/entity"
+
+// Writer encodes the cells of a read as they are streamed from Bigtable.
+type Writer interface {
+	Write(output entity.BigtableOutput) error
+	// Flush sends the rows encoded so far, when the format allows it.
+	Flush() error
+	// Close writes what is left of the file, which is valid even without rows.
+	Close() error
+}
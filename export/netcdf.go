@@ -0,0 +1,357 @@
+package export
+
+import (
+	"bigtable_api/entity"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// NetCDFFillValue marks the missing values, the NetCDF default for doubles.
+const NetCDFFillValue = 9.9692099683868690e+36
+
+// netCDF classic format tags and types.
+const (
+	ncDimension = 0x0A
+	ncVariable  = 0x0B
+	ncAttribute = 0x0C
+	ncChar      = 2
+	ncDouble    = 6
+)
+
+// NetCDFWriter writes cells as a CF timeSeries file, the format read by xarray
+// or CDO: a time and a station dimension, the station positions taken from
+// the payloads and a variable of (time, station) per weather variable.
+//
+// A NetCDF header holds the size of every dimension, so the cells are kept in
+// memory until Close. Only the latest version of each key is written.
+type NetCDFWriter struct {
+	output    io.Writer
+	dataTypes map[string]bool
+	stations  map[string]*station
+	times     map[int64]bool
+	variables map[string]float64
+	values    map[observation]map[string]float64
+}
+
+type station struct {
+	lon, lat float64
+	located  bool
+}
+
+type observation struct {
+	area string
+	time int64
+}
+
+func NewNetCDFWriter(w io.Writer) *NetCDFWriter {
+	return &NetCDFWriter{
+		output:    w,
+		dataTypes: make(map[string]bool),
+		stations:  make(map[string]*station),
+		times:     make(map[int64]bool),
+		variables: make(map[string]float64),
+		values:    make(map[observation]map[string]float64),
+	}
+}
+
+func (n *NetCDFWriter) Write(output entity.BigtableOutput) error {
+	key, err := entity.ParseKey(output.Key)
+	if err != nil {
+		// a cell can not be placed without its area and date
+		return nil
+	}
+	at := observation{area: key.AreaID, time: key.Date.Unix()}
+	if _, ok := n.values[at]; ok {
+		// an older version of the key
+		return nil
+	}
+	payload, _ := entity.DecodePayload(output.Value)
+
+	n.dataTypes[key.DataType] = true
+	n.times[at.time] = true
+	s, ok := n.stations[key.AreaID]
+	if !ok {
+		s = &station{}
+		n.stations[key.AreaID] = s
+	}
+	if lon, ok := payload.Lon(); ok && !s.located {
+		lat, _ := payload.Lat()
+		s.lon, s.lat, s.located = lon, lat, true
+	}
+	for name := range payload.Variables {
+		n.variables[name] = 0
+	}
+	n.values[at] = payload.Variables
+	return nil
+}
+
+// Flush does nothing, the file is written on Close.
+func (n *NetCDFWriter) Flush() error {
+	return nil
+}
+
+// Close writes the file.
+func (n *NetCDFWriter) Close() error {
+	times := make([]int64, 0, len(n.times))
+	for t := range n.times {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	areas := make([]string, 0, len(n.stations))
+	strlen := 1
+	for area := range n.stations {
+		areas = append(areas, area)
+		strlen = max(strlen, len(area))
+	}
+	sort.Strings(areas)
+	variables := entity.Payload{Variables: n.variables}.VariableNames()
+
+	file := &netCDF{numRecords: len(times)}
+	file.attributes = []ncAttr{
+		{"Conventions", "CF-1.8"},
+		{"featureType", "timeSeries"},
+		{"title", "climate data"},
+		{"source", "bigtable-api"},
+		{"history", time.Now().UTC().Format(time.RFC3339) + " exported from the climate_data table"},
+	}
+	if len(n.dataTypes) == 1 {
+		for dataType := range n.dataTypes {
+			file.attributes = append(file.attributes, ncAttr{"datatype", dataType})
+		}
+	}
+
+	// time is the record dimension, and a NetCDF dimension other than it can
+	// not be empty
+	file.dimensions = []ncDim{{"time", 0}}
+	file.variables = append(file.variables, ncVar{
+		name: "time", dims: []int{0}, record: true, size: 8,
+		attributes: []ncAttr{
+			{"standard_name", "time"},
+			{"long_name", "time"},
+			{"units", "seconds since 1970-01-01 00:00:00"},
+			{"calendar", "standard"},
+			{"axis", "T"},
+		},
+		write: func(w *bufio.Writer, record int) { writeDouble(w, float64(times[record])) },
+	})
+	if len(areas) > 0 {
+		file.dimensions = append(file.dimensions, ncDim{"station", len(areas)}, ncDim{"name_strlen", strlen})
+		file.variables = append(file.variables,
+			ncVar{
+				name: "station_id", dims: []int{1, 2}, typ: ncChar, size: len(areas) * strlen,
+				attributes: []ncAttr{{"long_name", "area ID"}, {"cf_role", "timeseries_id"}},
+				write: func(w *bufio.Writer, _ int) {
+					for _, area := range areas {
+						w.WriteString(area)
+						w.Write(make([]byte, strlen-len(area)))
+					}
+				},
+			},
+			n.coordinate("lon", "longitude", "degrees_east", areas, func(s *station) float64 { return s.lon }),
+			n.coordinate("lat", "latitude", "degrees_north", areas, func(s *station) float64 { return s.lat }),
+		)
+		for _, name := range variables {
+			name := name
+			attributes := []ncAttr{{"long_name", name}}
+			if info, ok := entity.Variable(name); ok {
+				attributes = []ncAttr{{"long_name", info.LongName}}
+				if info.StandardName != "" {
+					attributes = append(attributes, ncAttr{"standard_name", info.StandardName})
+				}
+				attributes = append(attributes, ncAttr{"units", info.Units}, ncAttr{"cell_methods", info.CellMethods})
+			}
+			attributes = append(attributes, ncAttr{"coordinates", "lat lon station_id"}, ncAttr{"_FillValue", NetCDFFillValue})
+			file.variables = append(file.variables, ncVar{
+				name: name, dims: []int{0, 1}, record: true, size: 8 * len(areas), attributes: attributes,
+				write: func(w *bufio.Writer, record int) {
+					for _, area := range areas {
+						value, ok := n.values[observation{area: area, time: times[record]}][name]
+						if !ok {
+							value = NetCDFFillValue
+						}
+						writeDouble(w, value)
+					}
+				},
+			})
+		}
+	}
+	return file.write(n.output)
+}
+
+// coordinate is a variable of the station positions.
+func (n *NetCDFWriter) coordinate(name, standardName, units string, areas []string, value func(*station) float64) ncVar {
+	return ncVar{
+		name: name, dims: []int{1}, size: 8 * len(areas),
+		attributes: []ncAttr{
+			{"standard_name", standardName},
+			{"long_name", standardName},
+			{"units", units},
+			{"_FillValue", NetCDFFillValue},
+		},
+		write: func(w *bufio.Writer, _ int) {
+			for _, area := range areas {
+				s := n.stations[area]
+				if !s.located {
+					writeDouble(w, NetCDFFillValue)
+					continue
+				}
+				writeDouble(w, value(s))
+			}
+		},
+	}
+}
+
+// netCDF is a file in the classic format with 64-bit offsets (CDF-2), as
+// described in https://docs.unidata.ucar.edu/netcdf-c/current/file_format_specifications.html.
+type netCDF struct {
+	numRecords int
+	dimensions []ncDim
+	attributes []ncAttr
+	variables  []ncVar
+}
+
+type ncDim struct {
+	name string
+	// length is zero for the record dimension
+	length int
+}
+
+type ncAttr struct {
+	name string
+	// value is a string or a float64
+	value interface{}
+}
+
+type ncVar struct {
+	name       string
+	dims       []int
+	attributes []ncAttr
+	// typ is ncDouble when not set
+	typ byte
+	// record variables have a value per record, of size bytes
+	record bool
+	size   int
+	// write writes the data of a record, or the whole variable
+	write func(w *bufio.Writer, record int)
+}
+
+func (f *netCDF) write(output io.Writer) error {
+	// the header length does not depend on the offsets written in it
+	begins := make([]int64, len(f.variables))
+	offset := int64(len(f.header(begins)))
+	for i, v := range f.variables {
+		if !v.record {
+			begins[i] = offset
+			offset += int64(pad(v.size))
+		}
+	}
+	for i, v := range f.variables {
+		if v.record {
+			begins[i] = offset
+			offset += int64(pad(v.size))
+		}
+	}
+
+	w := bufio.NewWriter(output)
+	w.Write(f.header(begins))
+	for _, v := range f.variables {
+		if !v.record {
+			v.write(w, 0)
+			w.Write(make([]byte, pad(v.size)-v.size))
+		}
+	}
+	for record := 0; record < f.numRecords; record++ {
+		for _, v := range f.variables {
+			if v.record {
+				v.write(w, record)
+				w.Write(make([]byte, pad(v.size)-v.size))
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func (f *netCDF) header(begins []int64) []byte {
+	var b bytes.Buffer
+	b.WriteString("CDF\x02")
+	writeInt(&b, f.numRecords)
+
+	writeInt(&b, ncDimension)
+	writeInt(&b, len(f.dimensions))
+	for _, dim := range f.dimensions {
+		writeName(&b, dim.name)
+		writeInt(&b, dim.length)
+	}
+
+	writeAttributes(&b, f.attributes)
+
+	writeInt(&b, ncVariable)
+	writeInt(&b, len(f.variables))
+	for i, v := range f.variables {
+		writeName(&b, v.name)
+		writeInt(&b, len(v.dims))
+		for _, dim := range v.dims {
+			writeInt(&b, dim)
+		}
+		writeAttributes(&b, v.attributes)
+		typ := v.typ
+		if typ == 0 {
+			typ = ncDouble
+		}
+		writeInt(&b, int(typ))
+		// vsize only overflows for variables no reader relies on it for
+		writeInt(&b, int(min(int64(pad(v.size)), math.MaxUint32)))
+		binary.Write(&b, binary.BigEndian, begins[i])
+	}
+	return b.Bytes()
+}
+
+func writeAttributes(b *bytes.Buffer, attributes []ncAttr) {
+	if len(attributes) == 0 {
+		// ABSENT
+		writeInt(b, 0)
+		writeInt(b, 0)
+		return
+	}
+	writeInt(b, ncAttribute)
+	writeInt(b, len(attributes))
+	for _, attribute := range attributes {
+		writeName(b, attribute.name)
+		switch value := attribute.value.(type) {
+		case string:
+			writeInt(b, ncChar)
+			writeName(b, value)
+		case float64:
+			writeInt(b, ncDouble)
+			writeInt(b, 1)
+			binary.Write(b, binary.BigEndian, value)
+		}
+	}
+}
+
+// writeName writes a length and the padded characters of a string.
+func writeName(b *bytes.Buffer, name string) {
+	writeInt(b, len(name))
+	b.WriteString(name)
+	b.Write(make([]byte, pad(len(name))-len(name)))
+}
+
+func writeInt(b *bytes.Buffer, value int) {
+	binary.Write(b, binary.BigEndian, uint32(value))
+}
+
+func writeDouble(w *bufio.Writer, value float64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(value))
+	w.Write(buf[:])
+}
+
+// pad rounds a size up to the 4 bytes boundary of the format.
+func pad(size int) int {
+	return (size + 3) &^ 3
+}
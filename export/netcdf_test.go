@@ -0,0 +1,187 @@
+package export_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/export"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NetCDFSuite struct {
+	suite.Suite
+}
+
+func TestNetCDFSuite(t *testing.T) {
+	suite.Run(t, new(NetCDFSuite))
+}
+
+// cdf is a file decoded by a minimal reader of the classic format.
+type cdf struct {
+	records    int
+	dimensions []cdfDim
+	attributes map[string]interface{}
+	variables  map[string]*cdfVar
+	data       []byte
+}
+
+type cdfDim struct {
+	name   string
+	length int
+}
+
+type cdfVar struct {
+	dims       []string
+	attributes map[string]interface{}
+	typ        int
+	size       int
+	begin      int64
+	record     bool
+}
+
+type cdfReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *cdfReader) int() int {
+	value := binary.BigEndian.Uint32(r.data[r.offset:])
+	r.offset += 4
+	return int(value)
+}
+
+func (r *cdfReader) name() string {
+	n := r.int()
+	name := string(r.data[r.offset : r.offset+n])
+	r.offset += (n + 3) &^ 3
+	return name
+}
+
+func (r *cdfReader) attributes() map[string]interface{} {
+	attributes := make(map[string]interface{})
+	r.int()
+	n := r.int()
+	for i := 0; i < n; i++ {
+		name := r.name()
+		switch r.int() {
+		case 2:
+			attributes[name] = r.name()
+		case 6:
+			r.int()
+			attributes[name] = math.Float64frombits(binary.BigEndian.Uint64(r.data[r.offset:]))
+			r.offset += 8
+		}
+	}
+	return attributes
+}
+
+func (n *NetCDFSuite) decode(data []byte) *cdf {
+	n.Require().Equal("CDF\x02", string(data[:4]))
+	r := &cdfReader{data: data, offset: 4}
+	file := &cdf{records: r.int(), variables: make(map[string]*cdfVar), data: data}
+	r.int()
+	for i, dims := 0, r.int(); i < dims; i++ {
+		file.dimensions = append(file.dimensions, cdfDim{r.name(), r.int()})
+	}
+	file.attributes = r.attributes()
+	r.int()
+	for i, vars := 0, r.int(); i < vars; i++ {
+		name := r.name()
+		v := &cdfVar{}
+		for j, dims := 0, r.int(); j < dims; j++ {
+			dim := file.dimensions[r.int()]
+			v.dims = append(v.dims, dim.name)
+			v.record = v.record || dim.length == 0
+		}
+		v.attributes = r.attributes()
+		v.typ = r.int()
+		v.size = r.int()
+		v.begin = int64(binary.BigEndian.Uint64(data[r.offset:]))
+		r.offset += 8
+		file.variables[name] = v
+	}
+	return file
+}
+
+// doubles reads a non record variable, or the values of a record variable
+// in every record.
+func (f *cdf) doubles(name string) []float64 {
+	v := f.variables[name]
+	recordSize := 0
+	for _, other := range f.variables {
+		if other.record {
+			recordSize += other.size
+		}
+	}
+	var values []float64
+	read := func(offset int64) {
+		for i := 0; i < v.size/8; i++ {
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(f.data[offset+int64(8*i):])))
+		}
+	}
+	if !v.record {
+		read(v.begin)
+		return values
+	}
+	for record := 0; record < f.records; record++ {
+		read(v.begin + int64(record*recordSize))
+	}
+	return values
+}
+
+func (n *NetCDFSuite) TestTimeSeries() {
+	var buf bytes.Buffer
+	writer := export.NewNetCDFWriter(&buf)
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	for _, output := range []entity.BigtableOutput{
+		{Key: "w/A327734/2023-10-10 00:00:00", Created: created, Value: `{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":28.79,"rain":0}}`},
+		{Key: "w/A327734/2023-10-10 00:00:00", Created: created.Add(-time.Hour), Value: `{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":1,"rain":1}}`},
+		{Key: "w/A327734/2023-10-10 01:00:00", Created: created, Value: `{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":27.5,"rain":1.2}}`},
+		{Key: "w/A42/2023-10-10 01:00:00", Created: created, Value: `{"lonlat":[-47.1,-19.2],"weatherData":{"temperatureInst":30.25,"custom":3}}`},
+	} {
+		n.Nil(writer.Write(output))
+	}
+	n.Nil(writer.Close())
+
+	file := n.decode(buf.Bytes())
+	n.Equal(2, file.records)
+	n.Equal([]cdfDim{{"time", 0}, {"station", 2}, {"name_strlen", 7}}, file.dimensions)
+	n.Equal("CF-1.8", file.attributes["Conventions"])
+	n.Equal("timeSeries", file.attributes["featureType"])
+	n.Equal("w", file.attributes["datatype"])
+
+	n.Equal("seconds since 1970-01-01 00:00:00", file.variables["time"].attributes["units"])
+	n.Equal([]float64{1696896000, 1696899600}, file.doubles("time"))
+	n.Equal([]string{"station", "name_strlen"}, file.variables["station_id"].dims)
+	n.Equal("timeseries_id", file.variables["station_id"].attributes["cf_role"])
+	stationID := file.variables["station_id"]
+	n.Equal("A327734A42\x00\x00\x00\x00", string(file.data[stationID.begin:stationID.begin+14]))
+	n.Equal([]float64{-50.59667, -47.1}, file.doubles("lon"))
+	n.Equal([]float64{-17.749189, -19.2}, file.doubles("lat"))
+	n.Equal("degrees_north", file.variables["lat"].attributes["units"])
+
+	temperature := file.variables["temperatureInst"]
+	n.Equal([]string{"time", "station"}, temperature.dims)
+	n.Equal("air_temperature", temperature.attributes["standard_name"])
+	n.Equal("degC", temperature.attributes["units"])
+	n.Equal("lat lon station_id", temperature.attributes["coordinates"])
+	n.Equal([]float64{28.79, export.NetCDFFillValue, 27.5, 30.25}, file.doubles("temperatureInst"))
+	n.Equal("mm", file.variables["rain"].attributes["units"])
+	n.Equal([]float64{0, export.NetCDFFillValue, 1.2, export.NetCDFFillValue}, file.doubles("rain"))
+	n.Equal("custom", file.variables["custom"].attributes["long_name"])
+	n.Nil(file.variables["custom"].attributes["units"])
+}
+
+func (n *NetCDFSuite) TestEmpty() {
+	var buf bytes.Buffer
+	n.Nil(export.NewNetCDFWriter(&buf).Close())
+	file := n.decode(buf.Bytes())
+	n.Equal(0, file.records)
+	n.Equal([]cdfDim{{"time", 0}}, file.dimensions)
+	n.Len(file.variables, 1)
+	n.Equal(int64(buf.Len()), file.variables["time"].begin)
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bigtable_api/entity"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ParquetRowGroupRows is the number of rows of each row group. The writer
+// holds a row group in memory before writing it, so it bounds the memory of an
+// export, while being large enough for readers to scan columns efficiently.
+const ParquetRowGroupRows = 128 * 1024
+
+// ParquetWriter writes cells as a typed columnar file: timestamps in
+// milliseconds, dictionary encoded datatype and area_id, and a float64 column
+// per variable. Like CSVWriter, the variables are the columns given, and
+// entity.WeatherVariables by default.
+type ParquetWriter struct {
+	output    io.Writer
+	variables []string
+	writer    *parquet.Writer
+	columns   map[string]int
+	row       parquet.Row
+}
+
+func NewParquetWriter(w io.Writer, variables []string) *ParquetWriter {
+	if variables == nil {
+		variables = entity.WeatherVariables
+	}
+	return &ParquetWriter{output: w, variables: variables}
+}
+
+func (p *ParquetWriter) Write(output entity.BigtableOutput) error {
+	payload, _ := entity.DecodePayload(output.Value)
+	if p.writer == nil {
+		p.open()
+	}
+
+	for i := range p.row {
+		p.row[i] = parquet.NullValue().Level(0, 0, i)
+	}
+	if key, err := entity.ParseKey(output.Key); err == nil {
+		p.set("datatype", parquet.ByteArrayValue([]byte(key.DataType)))
+		p.set("area_id", parquet.ByteArrayValue([]byte(key.AreaID)))
+		p.set("timestamp", parquet.Int64Value(key.Date.UnixMilli()))
+	}
+	p.set("created", parquet.Int64Value(output.Created.UnixMilli()))
+	if lon, ok := payload.Lon(); ok {
+		p.set("lon", parquet.DoubleValue(lon))
+	}
+	if lat, ok := payload.Lat(); ok {
+		p.set("lat", parquet.DoubleValue(lat))
+	}
+	for name, value := range payload.Variables {
+		p.set(name, parquet.DoubleValue(value))
+	}
+	_, err := p.writer.WriteRows([]parquet.Row{p.row})
+	return err
+}
+
+// Flush does nothing, the rows are written a row group at a time.
+func (p *ParquetWriter) Flush() error {
+	return nil
+}
+
+// Close writes the last row group and the footer.
+func (p *ParquetWriter) Close() error {
+	if p.writer == nil {
+		p.open()
+	}
+	return p.writer.Close()
+}
+
+// open creates the schema with the key columns and the variables. Every
+// column is optional, since cells may lack any of them.
+func (p *ParquetWriter) open() {
+	dictionary := func() parquet.Node {
+		return parquet.Optional(parquet.Encoded(parquet.String(), &parquet.RLEDictionary))
+	}
+	group := parquet.Group{
+		"datatype":  dictionary(),
+		"area_id":   dictionary(),
+		"timestamp": parquet.Optional(parquet.Timestamp(parquet.Millisecond)),
+		"created":   parquet.Optional(parquet.Timestamp(parquet.Millisecond)),
+		"lon":       parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+		"lat":       parquet.Optional(parquet.Leaf(parquet.DoubleType)),
+	}
+	for _, name := range p.variables {
+		if _, ok := group[name]; !ok {
+			group[name] = parquet.Optional(parquet.Leaf(parquet.DoubleType))
+		}
+	}
+	schema := parquet.NewSchema("climate_data", group)
+
+	// the columns of a group are sorted by name
+	p.columns = make(map[string]int, len(group))
+	for i, field := range schema.Fields() {
+		p.columns[field.Name()] = i
+	}
+	p.row = make(parquet.Row, len(group))
+	p.writer = parquet.NewWriter(p.output,
+		schema,
+		parquet.MaxRowsPerRowGroup(ParquetRowGroupRows),
+		parquet.Compression(&parquet.Snappy),
+	)
+}
+
+// set fills the column of a value, ignoring variables missing from the schema.
+func (p *ParquetWriter) set(column string, value parquet.Value) {
+	if i, ok := p.columns[column]; ok {
+		p.row[i] = value.Level(0, 1, i)
+	}
+}
@@ -0,0 +1,122 @@
+package export_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/export"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/suite"
+)
+
+type ParquetSuite struct {
+	suite.Suite
+}
+
+func TestParquetSuite(t *testing.T) {
+	suite.Run(t, new(ParquetSuite))
+}
+
+// read opens the file written and returns its rows by column name.
+func (p *ParquetSuite) read(data []byte) (*parquet.File, []map[string]parquet.Value) {
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	p.Require().Nil(err)
+	reader := parquet.NewReader(file)
+	var rows []map[string]parquet.Value
+	for {
+		row := make([]parquet.Row, 1)
+		n, _ := reader.ReadRows(row)
+		if n == 0 {
+			break
+		}
+		values := make(map[string]parquet.Value)
+		for i, field := range file.Schema().Fields() {
+			values[field.Name()] = row[0][i]
+		}
+		rows = append(rows, values)
+	}
+	return file, rows
+}
+
+func (p *ParquetSuite) TestTypedColumns() {
+	var buf bytes.Buffer
+	writer := export.NewParquetWriter(&buf, nil)
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	p.Nil(writer.Write(entity.BigtableOutput{
+		Key:     "w/A327734/2023-10-10 00:00:00",
+		Created: created,
+		Value:   `{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":28.79,"rain":0}}`,
+	}))
+	p.Nil(writer.Write(entity.BigtableOutput{
+		Key:     "w/A327735/2023-10-10 01:00:00",
+		Created: created,
+		Value:   `{"lonlat":[-50.1,-17.2],"weatherData":{"temperatureInst":27.5}}`,
+	}))
+	p.Nil(writer.Close())
+
+	file, rows := p.read(buf.Bytes())
+	p.Equal(int64(2), file.NumRows())
+	for _, name := range []string{"timestamp", "created"} {
+		column, _ := file.Schema().Lookup(name)
+		p.Equal("TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)", column.Node.Type().String(), name)
+	}
+	for _, name := range []string{"lon", "lat", "temperatureInst", "rain"} {
+		column, _ := file.Schema().Lookup(name)
+		p.Equal(parquet.Double, column.Node.Type().Kind(), name)
+	}
+	area, _ := file.Schema().Lookup("area_id")
+	p.Equal(&parquet.RLEDictionary, area.Node.Encoding())
+
+	p.Require().Len(rows, 2)
+	p.Equal("w", rows[0]["datatype"].String())
+	p.Equal("A327734", rows[0]["area_id"].String())
+	p.Equal(time.Date(2023, 10, 10, 0, 0, 0, 0, time.UTC).UnixMilli(), rows[0]["timestamp"].Int64())
+	p.Equal(created.UnixMilli(), rows[0]["created"].Int64())
+	p.Equal(-50.59667, rows[0]["lon"].Double())
+	p.Equal(28.79, rows[0]["temperatureInst"].Double())
+	p.Equal(0.0, rows[0]["rain"].Double())
+	p.False(rows[0]["rain"].IsNull())
+	p.Equal("A327735", rows[1]["area_id"].String())
+	p.True(rows[1]["rain"].IsNull())
+}
+
+func (p *ParquetSuite) TestEmpty() {
+	var buf bytes.Buffer
+	p.Nil(export.NewParquetWriter(&buf, nil).Close())
+	file, rows := p.read(buf.Bytes())
+	p.Equal(int64(0), file.NumRows())
+	p.Len(file.Schema().Fields(), 6+len(entity.WeatherVariables))
+	p.Empty(rows)
+}
+
+func (p *ParquetSuite) TestColumns() {
+	var buf bytes.Buffer
+	writer := export.NewParquetWriter(&buf, []string{"rain", "dewPoint"})
+	// the first cell has no variable, and the columns still are those given
+	p.Nil(writer.Write(entity.BigtableOutput{Key: "w/A327734/2023-10-10 00:00:00", Value: "not a payload"}))
+	p.Nil(writer.Write(entity.BigtableOutput{Key: "w/A327734/2023-10-10 01:00:00", Value: `{"weatherData":{"temperatureInst":27.5,"dewPoint":19.51}}`}))
+	p.Nil(writer.Close())
+
+	file, rows := p.read(buf.Bytes())
+	p.Len(file.Schema().Fields(), 8)
+	_, found := file.Schema().Lookup("temperatureInst")
+	p.False(found)
+	p.Require().Len(rows, 2)
+	p.True(rows[0]["dewPoint"].IsNull())
+	p.Equal(19.51, rows[1]["dewPoint"].Double())
+	p.True(rows[1]["rain"].IsNull())
+}
+
+func (p *ParquetSuite) TestRowGroups() {
+	var buf bytes.Buffer
+	writer := export.NewParquetWriter(&buf, nil)
+	for i := 0; i < export.ParquetRowGroupRows+1; i++ {
+		p.Require().Nil(writer.Write(entity.BigtableOutput{Key: "w/A327734/2023-10-10 00:00:00", Value: `{"weatherData":{"rain":1}}`}))
+	}
+	p.Nil(writer.Close())
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	p.Require().Nil(err)
+	p.Len(file.RowGroups(), 2)
+}
@@ -0,0 +1,325 @@
+// Package filterexpr parses the filter expressions of the reads, such as
+//
+//	key ~ ".*00:00:00" AND created > 2023-10-01 AND NOT family = "raw"
+//
+// into Bigtable filters: AND becomes a chain, OR an interleave and the
+// negation of a key comparison a condition.
+//
+// The comparisons are:
+//
+//   - key, family, column and value with = and != for an exact value, or ~
+//     and !~ for an RE2 expression that must match the whole of it
+//   - created with =, !=, >, >=, < and <= for a date, as 2023-10-01 or
+//     2023-10-01T12:00:00-03:00, in the location given to Parse when it has
+//     no offset
+//
+// Values are double quoted strings, with Go escapes, or bare words. AND binds
+// tighter than OR, and parentheses group terms. The keywords are case
+// insensitive.
+//
+// Negations are pushed down to the comparisons. Bigtable can only negate the
+// filters of whole rows, which is exact for the keys and the created dates
+// but not for the family, column and value of the cells: the filters of
+// those negations pass every cell, and the cells are then selected by Match.
+//
+// ParseWhere parses, with the same syntax, the predicates on the variables of
+// the payloads, which Bigtable can not evaluate.
+package filterexpr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// Expr is a parsed expression.
+type Expr interface {
+	// Filter returns the Bigtable filter selecting the cells of the expression,
+	// or more of them when it is not Exact.
+	Filter() bigtable.Filter
+	// Match reports whether a cell matches the expression.
+	Match(item bigtable.ReadItem) bool
+	String() string
+	negate() Expr
+}
+
+// And holds terms that must all match, and Or terms of which one must match.
+type (
+	And []Expr
+	Or  []Expr
+)
+
+// Comparison compares a field of the cells to a value.
+type Comparison struct {
+	Field string
+	Op    string
+	Value string
+	// date is the value of created comparisons, truncated to milliseconds as
+	// the cell timestamps, and re the expression of ~ and !~ comparisons,
+	// anchored to match the whole of the field.
+	date time.Time
+	re   *regexp.Regexp
+}
+
+// newAnd and newOr join terms, flattening the nested ones of the same kind.
+func newAnd(terms []Expr) Expr {
+	var and And
+	for _, term := range terms {
+		if nested, ok := term.(And); ok {
+			and = append(and, nested...)
+		} else {
+			and = append(and, term)
+		}
+	}
+	return and
+}
+
+func newOr(terms []Expr) Expr {
+	var or Or
+	for _, term := range terms {
+		if nested, ok := term.(Or); ok {
+			or = append(or, nested...)
+		} else {
+			or = append(or, term)
+		}
+	}
+	return or
+}
+
+func (a And) Filter() bigtable.Filter {
+	filters := make([]bigtable.Filter, len(a))
+	for i, term := range a {
+		filters[i] = term.Filter()
+	}
+	return bigtable.ChainFilters(filters...)
+}
+
+func (a And) Match(item bigtable.ReadItem) bool {
+	for _, term := range a {
+		if !term.Match(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a And) String() string {
+	terms := make([]string, len(a))
+	for i, term := range a {
+		terms[i] = term.String()
+		if _, ok := term.(Or); ok {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func (a And) negate() Expr {
+	negated := make(Or, len(a))
+	for i, term := range a {
+		negated[i] = term.negate()
+	}
+	return negated
+}
+
+func (o Or) Filter() bigtable.Filter {
+	filters := o.filters()
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return bigtable.InterleaveFilters(filters...)
+}
+
+// filters returns the filters of the terms, merging the positive comparisons
+// of a same field into a single expression so that they need no interleave.
+func (o Or) filters() []bigtable.Filter {
+	var filters []bigtable.Filter
+	patterns := make(map[string][]string)
+	var fields []string
+	for _, term := range o {
+		comparison, ok := term.(*Comparison)
+		if !ok || comparison.Field == "created" || comparison.negative() {
+			filters = append(filters, term.Filter())
+			continue
+		}
+		if _, ok := patterns[comparison.Field]; !ok {
+			fields = append(fields, comparison.Field)
+		}
+		patterns[comparison.Field] = append(patterns[comparison.Field], comparison.pattern())
+	}
+	for _, field := range fields {
+		pattern := patterns[field][0]
+		if len(patterns[field]) > 1 {
+			pattern = "(?:" + strings.Join(patterns[field], ")|(?:") + ")"
+		}
+		filters = append(filters, fieldFilter(field, pattern))
+	}
+	return filters
+}
+
+func (o Or) Match(item bigtable.ReadItem) bool {
+	for _, term := range o {
+		if term.Match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Or) String() string {
+	terms := make([]string, len(o))
+	for i, term := range o {
+		terms[i] = term.String()
+	}
+	return strings.Join(terms, " OR ")
+}
+
+func (o Or) negate() Expr {
+	negated := make(And, len(o))
+	for i, term := range o {
+		negated[i] = term.negate()
+	}
+	return negated
+}
+
+func (c *Comparison) Filter() bigtable.Filter {
+	if c.Field == "created" {
+		var zero time.Time
+		next := c.date.Add(time.Millisecond)
+		switch c.Op {
+		case "=":
+			return bigtable.TimestampRangeFilter(c.date, next)
+		case "!=":
+			return bigtable.InterleaveFilters(bigtable.TimestampRangeFilter(zero, c.date), bigtable.TimestampRangeFilter(next, zero))
+		case ">":
+			return bigtable.TimestampRangeFilter(next, zero)
+		case ">=":
+			return bigtable.TimestampRangeFilter(c.date, zero)
+		case "<":
+			return bigtable.TimestampRangeFilter(zero, c.date)
+		default:
+			return bigtable.TimestampRangeFilter(zero, next)
+		}
+	}
+	filter := fieldFilter(c.Field, c.pattern())
+	switch {
+	case !c.negative():
+		return filter
+	case c.Field == "key":
+		return bigtable.ConditionFilter(filter, bigtable.BlockAllFilter(), bigtable.PassAllFilter())
+	default:
+		// the other cells of the row may not match, so the cells are left to Match
+		return bigtable.PassAllFilter()
+	}
+}
+
+func (c *Comparison) Match(item bigtable.ReadItem) bool {
+	if c.Field == "created" {
+		created := item.Timestamp.Time().Truncate(time.Millisecond)
+		switch c.Op {
+		case "=":
+			return created.Equal(c.date)
+		case "!=":
+			return !created.Equal(c.date)
+		case ">":
+			return created.After(c.date)
+		case ">=":
+			return !created.Before(c.date)
+		case "<":
+			return created.Before(c.date)
+		default:
+			return !created.After(c.date)
+		}
+	}
+	family, column, _ := strings.Cut(item.Column, ":")
+	field := map[string]string{"key": item.Row, "family": family, "column": column, "value": string(item.Value)}[c.Field]
+	matched := field == c.Value
+	if c.re != nil {
+		matched = c.re.MatchString(field)
+	}
+	return matched != c.negative()
+}
+
+// negative reports whether the comparison selects the cells that do not match
+// its value.
+func (c *Comparison) negative() bool {
+	return c.Op == "!=" || c.Op == "!~"
+}
+
+func (c *Comparison) String() string {
+	return c.Field + " " + c.Op + " " + strconv.Quote(c.Value)
+}
+
+func (c *Comparison) negate() Expr {
+	negated := *c
+	negated.Op = map[string]string{
+		"=": "!=", "!=": "=", "~": "!~", "!~": "~",
+		">": "<=", "<=": ">", "<": ">=", ">=": "<",
+	}[c.Op]
+	return &negated
+}
+
+// pattern returns the RE2 expression matching the value of the comparison.
+func (c *Comparison) pattern() string {
+	if c.Op == "~" || c.Op == "!~" {
+		return c.Value
+	}
+	return regexp.QuoteMeta(c.Value)
+}
+
+func fieldFilter(field, pattern string) bigtable.Filter {
+	switch field {
+	case "key":
+		return bigtable.RowKeyFilter(pattern)
+	case "family":
+		return bigtable.FamilyFilter(pattern)
+	case "column":
+		return bigtable.ColumnFilter(pattern)
+	default:
+		return bigtable.ValueFilter(pattern)
+	}
+}
+
+// Exact reports whether the filter of e selects exactly the cells of the
+// expression, and false when it holds negations of family, column or value
+// comparisons, whose cells must be selected by Match.
+func Exact(e Expr) bool {
+	switch e := e.(type) {
+	case And:
+		for _, term := range e {
+			if !Exact(term) {
+				return false
+			}
+		}
+	case Or:
+		for _, term := range e {
+			if !Exact(term) {
+				return false
+			}
+		}
+	case *Comparison:
+		return e.Field == "key" || e.Field == "created" || !e.negative()
+	}
+	return true
+}
+
+// MayDuplicate reports whether the filter of e may return a cell more than
+// once, when the cell matches more than one side of an interleave. The number
+// of versions of such a read must then be limited after removing the
+// duplicates.
+func MayDuplicate(e Expr) bool {
+	switch e := e.(type) {
+	case And:
+		for _, term := range e {
+			if MayDuplicate(term) {
+				return true
+			}
+		}
+	case Or:
+		return len(e.filters()) > 1
+	}
+	return false
+}
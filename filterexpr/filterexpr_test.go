@@ -0,0 +1,161 @@
+package filterexpr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	for _, test := range []struct {
+		expr, filter string
+	}{
+		{`key ~ ".*00:00:00"`, `row(.*00:00:00)`},
+		{`key = "w/A327734/2023-10-10 00:00:00"`, `row(w/A327734/2023-10-10 00:00:00)`},
+		{`family = raw.v1`, `col(raw\.v1:)`},
+		{`column ~ "val.*"`, `col(.*:val.*)`},
+		{`value ~ ".*lonlat.*"`, `value_match(.*lonlat.*)`},
+		{
+			`key ~ ".*00:00" AND created > 2023-10-01 AND NOT family = "raw"`,
+			`(row(.*00:00) | timestamp_range(1696118400001000,0) | passAllFilter())`,
+		},
+		// a row holds a single key, so its negation is exact
+		{`key != a`, `conditionFilter(row(a),blockAllFilter(),passAllFilter())`},
+		{`created >= 2023-10-01T00:00:00-03:00`, `timestamp_range(1696129200000000,0)`},
+		{`created <= "2023-10-01 00:00:00"`, `timestamp_range(0,1696118400001000)`},
+		{`created = 2023-10-01`, `timestamp_range(1696118400000000,1696118400001000)`},
+		{`created != 2023-10-01`, `(timestamp_range(0,1696118400000000) + timestamp_range(1696118400001000,0))`},
+		// comparisons of a field are merged, the other ones interleaved
+		{`key ~ ".*00:00" or key = "w/A1/2023-10-10 03:00:00"`, `row((?:.*00:00)|(?:w/A1/2023-10-10 03:00:00))`},
+		{`key ~ ".*00:00" OR created < 2023-10-01`, `(timestamp_range(0,1696118400000000) + row(.*00:00))`},
+		// AND binds tighter than OR
+		{`key = a OR key = b AND value = c`, `((row(b) | value_match(c)) + row(a))`},
+		{`(key = a OR key = b) AND value = c`, `(row((?:a)|(?:b)) | value_match(c))`},
+	} {
+		expr, err := Parse(test.expr, time.UTC)
+		if assert.NoError(t, err, test.expr) {
+			assert.Equal(t, test.filter, expr.Filter().String(), test.expr)
+		}
+	}
+}
+
+func TestDateLocation(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the dates without an offset are in the location, the other ones at their offset
+	for expr, filter := range map[string]string{
+		`created >= 2023-10-01`:                    `timestamp_range(1696129200000000,0)`,
+		`created >= "2023-10-01 12:00:00"`:         `timestamp_range(1696172400000000,0)`,
+		`created >= 2023-10-01T00:00:00Z`:          `timestamp_range(1696118400000000,0)`,
+		`created >= 2023-10-01T00:00:00.000+02:00`: `timestamp_range(1696111200000000,0)`,
+	} {
+		parsed, err := Parse(expr, saoPaulo)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, filter, parsed.Filter().String(), expr)
+		}
+	}
+}
+
+func TestNegations(t *testing.T) {
+	for _, test := range []struct {
+		expr, normalized string
+	}{
+		{`NOT created > 2023-10-01`, `created <= "2023-10-01"`},
+		{`not not key = a`, `key = "a"`},
+		{`NOT (key ~ "a.*" AND created >= 2023-10-01)`, `key !~ "a.*" OR created < "2023-10-01"`},
+		{`NOT (key = a OR value != b) AND family = data`, `key != "a" AND value = "b" AND family = "data"`},
+	} {
+		expr, err := Parse(test.expr, time.UTC)
+		if assert.NoError(t, err, test.expr) {
+			assert.Equal(t, test.normalized, expr.String(), test.expr)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cell := bigtable.ReadItem{
+		Row:       "w/A327734/2023-10-10 00:00:00",
+		Column:    "raw:value",
+		Timestamp: bigtable.Time(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+		Value:     []byte(`{"lonlat":[1,2]}`),
+	}
+	for expr, matches := range map[string]bool{
+		`key ~ ".*00:00:00"`:                     true,
+		`key ~ "00:00:00"`:                       false,
+		`family = raw AND column = value`:        true,
+		`NOT family = "raw"`:                     false,
+		`family != data`:                         true,
+		`column !~ "val.*"`:                      false,
+		`value ~ ".*lonlat.*"`:                   true,
+		`created = 2023-10-01`:                   true,
+		`created > 2023-10-01 OR family = data`:  false,
+		`NOT (created < 2023-10-01 OR key = a)`:  true,
+		`created >= 2023-10-01 AND value != "x"`: true,
+	} {
+		parsed, err := Parse(expr, time.UTC)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, matches, parsed.Match(cell), expr)
+		}
+	}
+}
+
+func TestExact(t *testing.T) {
+	for expr, exact := range map[string]bool{
+		`key != a AND created != 2023-10-01`:                   true,
+		`family = raw OR value ~ ".*lonlat.*"`:                 true,
+		`NOT family = raw`:                                     false,
+		`key = a OR (created > 2023-10-01 AND value !~ "x.*")`: false,
+	} {
+		parsed, err := Parse(expr, time.UTC)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, exact, Exact(parsed), expr)
+		}
+	}
+}
+
+func TestMayDuplicate(t *testing.T) {
+	for expr, duplicates := range map[string]bool{
+		`key = a OR key = b`:                           false,
+		`created != 2023-10-01`:                        false,
+		`key = a OR created > 2023-10-01`:              true,
+		`value = b AND (key = a OR family = data)`:     true,
+		`NOT (key = a AND created > 2023-10-01)`:       true,
+		`key ~ ".*00:00" AND NOT created > 2023-10-01`: false,
+	} {
+		parsed, err := Parse(expr, time.UTC)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, duplicates, MayDuplicate(parsed), expr)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	for expr, offset := range map[string]int{
+		``:                              0,
+		`key`:                           3,
+		`key ~`:                         5,
+		`row = a`:                       0,
+		`created ~ "2023"`:              8,
+		`created > 2023-10-1`:           10,
+		`key ~ "(unclosed"`:             6,
+		`key = "unterminated`:           6,
+		`key = a AND`:                   11,
+		`(key = a OR key = b`:           19,
+		`key = a key = b`:               8,
+		`key ! a`:                       4,
+		`key = a OR OR key = b`:         11,
+		`NOT`:                           3,
+		`key = "bad \q escape" AND x=1`: 6,
+	} {
+		_, err := Parse(expr, time.UTC)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), expr) {
+			assert.Equal(t, offset, syntaxErr.Offset, "%s: %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package filterexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SyntaxError is an invalid expression, with the offset where it was found.
+type SyntaxError struct {
+	Offset  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+// fieldOps are the operators accepted by each field.
+var fieldOps = map[string][]string{
+	"key":     {"=", "!=", "~", "!~"},
+	"family":  {"=", "!=", "~", "!~"},
+	"column":  {"=", "!=", "~", "!~"},
+	"value":   {"=", "!=", "~", "!~"},
+	"created": {"=", "!=", ">", ">=", "<", "<="},
+}
+
+// dateLayouts are the accepted layouts of the created dates.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Parse parses an expression, with its negations pushed down to the
+// comparisons. The created dates without an offset are in loc.
+func Parse(s string, loc *time.Location) (Expr, error) {
+	return parse(s, grammar[Expr]{
+		comparison: func(l *lexer) (Expr, error) { return parseComparison(l, loc) },
+		and:        newAnd,
+		or:         newOr,
+		not:        func(expr Expr) Expr { return expr.negate() },
+	})
+}
+
+// grammar builds the nodes of an expression of comparisons combined by AND,
+// OR, NOT and parentheses.
+type grammar[T any] struct {
+	comparison func(l *lexer) (T, error)
+	and, or    func([]T) T
+	not        func(T) T
+}
+
+func parse[T any](s string, g grammar[T]) (T, error) {
+	p := &parser[T]{lexer: &lexer{input: s}, grammar: g}
+	p.next()
+	expr, err := p.parseOr()
+	if err != nil {
+		return expr, err
+	}
+	if p.token.kind != tokenEOF {
+		var zero T
+		return zero, p.errorf("unexpected %s", p.token)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenInvalid
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return "string " + strconv.Quote(t.text)
+	}
+	return strconv.Quote(t.text)
+}
+
+// isKeyword reports whether the token is the keyword, in any case.
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+type lexer struct {
+	input  string
+	offset int
+	token  token
+	err    error
+}
+
+type parser[T any] struct {
+	*lexer
+	grammar[T]
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	if l.err != nil {
+		return l.err
+	}
+	return &SyntaxError{Offset: l.token.offset, Message: fmt.Sprintf(format, args...)}
+}
+
+// next reads the next token of the input.
+func (l *lexer) next() {
+	for l.offset < len(l.input) && unicode.IsSpace(rune(l.input[l.offset])) {
+		l.offset++
+	}
+	start := l.offset
+	if start == len(l.input) {
+		l.token = token{kind: tokenEOF, offset: start}
+		return
+	}
+	switch c := l.input[start]; {
+	case c == '(':
+		l.offset++
+		l.token = token{kind: tokenLParen, text: "(", offset: start}
+	case c == ')':
+		l.offset++
+		l.token = token{kind: tokenRParen, text: ")", offset: start}
+	case strings.IndexByte("=!~<>", c) >= 0:
+		for _, op := range []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"} {
+			if strings.HasPrefix(l.input[start:], op) {
+				l.offset += len(op)
+				l.token = token{kind: tokenOp, text: op, offset: start}
+				return
+			}
+		}
+		l.offset++
+		l.token = token{kind: tokenInvalid, text: string(c), offset: start}
+	case c == '"':
+		end := start + 1
+		for end < len(l.input) && l.input[end] != '"' {
+			if l.input[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(l.input) {
+			l.err = &SyntaxError{Offset: start, Message: "unterminated string"}
+			l.offset = len(l.input)
+			l.token = token{kind: tokenInvalid, text: l.input[start:], offset: start}
+			return
+		}
+		l.offset = end + 1
+		text, err := strconv.Unquote(l.input[start:l.offset])
+		if err != nil {
+			l.err = &SyntaxError{Offset: start, Message: "invalid string " + l.input[start:l.offset]}
+			l.token = token{kind: tokenInvalid, text: l.input[start:l.offset], offset: start}
+			return
+		}
+		l.token = token{kind: tokenString, text: text, offset: start}
+	default:
+		end := start
+		for end < len(l.input) && !unicode.IsSpace(rune(l.input[end])) && strings.IndexByte(`()"=!~<>`, l.input[end]) < 0 {
+			end++
+		}
+		l.offset = end
+		l.token = token{kind: tokenWord, text: l.input[start:end], offset: start}
+	}
+}
+
+func (p *parser[T]) parseOr() (T, error) {
+	return p.parseTerms("OR", p.parseAnd, p.or)
+}
+
+func (p *parser[T]) parseAnd() (T, error) {
+	return p.parseTerms("AND", p.parseNot, p.and)
+}
+
+// parseTerms parses terms separated by a keyword, combining them with join
+// when there are more than one.
+func (p *parser[T]) parseTerms(keyword string, parseTerm func() (T, error), join func([]T) T) (T, error) {
+	term, err := parseTerm()
+	if err != nil {
+		return term, err
+	}
+	terms := []T{term}
+	for p.token.isKeyword(keyword) {
+		p.next()
+		if term, err = parseTerm(); err != nil {
+			return term, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return join(terms), nil
+}
+
+func (p *parser[T]) parseNot() (T, error) {
+	if !p.token.isKeyword("NOT") {
+		return p.parsePrimary()
+	}
+	p.next()
+	expr, err := p.parseNot()
+	if err != nil {
+		return expr, err
+	}
+	return p.not(expr), nil
+}
+
+func (p *parser[T]) parsePrimary() (T, error) {
+	if p.token.kind != tokenLParen {
+		return p.comparison(p.lexer)
+	}
+	p.next()
+	expr, err := p.parseOr()
+	if err != nil {
+		return expr, err
+	}
+	if p.token.kind != tokenRParen {
+		var zero T
+		return zero, p.errorf("expected ) instead of %s", p.token)
+	}
+	p.next()
+	return expr, nil
+}
+
+func parseComparison(l *lexer, loc *time.Location) (Expr, error) {
+	field := l.token
+	if field.kind != tokenWord {
+		return nil, l.errorf("expected a field instead of %s", field)
+	}
+	ops, ok := fieldOps[strings.ToLower(field.text)]
+	if !ok {
+		return nil, l.errorf("unknown field %q, expected key, family, column, value or created", field.text)
+	}
+	comparison := &Comparison{Field: strings.ToLower(field.text)}
+	l.next()
+
+	op := l.token
+	if op.kind != tokenOp {
+		return nil, l.errorf("expected an operator after %s instead of %s", comparison.Field, op)
+	}
+	if !contains(ops, op.text) {
+		return nil, l.errorf("operator %s can not compare %s, expected one of %s", op.text, comparison.Field, strings.Join(ops, " "))
+	}
+	comparison.Op = op.text
+	l.next()
+
+	value := l.token
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, l.errorf("expected a value instead of %s", value)
+	}
+	comparison.Value = value.text
+	switch {
+	case comparison.Field == "created":
+		date, err := parseDate(value.text, loc)
+		if err != nil {
+			return nil, l.errorf("invalid date %q, expected ISO-8601 such as 2023-10-01T00:00:00Z", value.text)
+		}
+		comparison.date = date
+	case comparison.Op == "~" || comparison.Op == "!~":
+		if _, err := regexp.Compile(value.text); err != nil {
+			return nil, l.errorf("invalid regexp %q, expected RE2 syntax", value.text)
+		}
+		comparison.re = regexp.MustCompile("^(?:" + value.text + ")$")
+	}
+	l.next()
+	return comparison, nil
+}
+
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var date time.Time
+		if date, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return date.UTC().Truncate(time.Millisecond), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
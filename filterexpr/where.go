@@ -0,0 +1,169 @@
+package filterexpr
+
+import (
+	"bigtable_api/entity"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Predicate selects cells by the variables of their payload, such as
+//
+//	rain > 0 OR temperatureMin < 2
+//
+// Unlike the expressions, predicates are evaluated on the decoded payloads, as
+// they are read. The comparisons are =, !=, >, >=, < and <= between a variable,
+// or lon and lat, and a number. A comparison on a variable missing from the
+// payload is false, also when negated.
+type Predicate interface {
+	Match(payload entity.Payload) bool
+	String() string
+	negate() Predicate
+}
+
+// AllOf holds predicates that must all match, and AnyOf predicates of which
+// one must match.
+type (
+	AllOf []Predicate
+	AnyOf []Predicate
+)
+
+// VariableComparison compares a variable of the payload to a number.
+type VariableComparison struct {
+	Variable string
+	Op       string
+	Value    float64
+}
+
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseWhere parses a predicate, with its negations pushed down to the
+// comparisons.
+func ParseWhere(s string) (Predicate, error) {
+	return parse(s, grammar[Predicate]{
+		comparison: parseVariableComparison,
+		and:        func(terms []Predicate) Predicate { return AllOf(terms) },
+		or:         func(terms []Predicate) Predicate { return AnyOf(terms) },
+		not:        func(predicate Predicate) Predicate { return predicate.negate() },
+	})
+}
+
+func (a AllOf) Match(payload entity.Payload) bool {
+	for _, term := range a {
+		if !term.Match(payload) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a AllOf) String() string {
+	terms := make([]string, len(a))
+	for i, term := range a {
+		terms[i] = term.String()
+		if _, ok := term.(AnyOf); ok {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func (a AllOf) negate() Predicate {
+	negated := make(AnyOf, len(a))
+	for i, term := range a {
+		negated[i] = term.negate()
+	}
+	return negated
+}
+
+func (a AnyOf) Match(payload entity.Payload) bool {
+	for _, term := range a {
+		if term.Match(payload) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a AnyOf) String() string {
+	terms := make([]string, len(a))
+	for i, term := range a {
+		terms[i] = term.String()
+	}
+	return strings.Join(terms, " OR ")
+}
+
+func (a AnyOf) negate() Predicate {
+	negated := make(AllOf, len(a))
+	for i, term := range a {
+		negated[i] = term.negate()
+	}
+	return negated
+}
+
+func (c *VariableComparison) Match(payload entity.Payload) bool {
+	var value float64
+	var ok bool
+	switch c.Variable {
+	case "lon":
+		value, ok = payload.Lon()
+	case "lat":
+		value, ok = payload.Lat()
+	default:
+		value, ok = payload.Variables[c.Variable]
+	}
+	if !ok {
+		return false
+	}
+	switch c.Op {
+	case "=":
+		return value == c.Value
+	case "!=":
+		return value != c.Value
+	case ">":
+		return value > c.Value
+	case ">=":
+		return value >= c.Value
+	case "<":
+		return value < c.Value
+	default:
+		return value <= c.Value
+	}
+}
+
+func (c *VariableComparison) String() string {
+	return c.Variable + " " + c.Op + " " + strconv.FormatFloat(c.Value, 'g', -1, 64)
+}
+
+func (c *VariableComparison) negate() Predicate {
+	negated := *c
+	negated.Op = map[string]string{
+		"=": "!=", "!=": "=", ">": "<=", "<=": ">", "<": ">=", ">=": "<",
+	}[c.Op]
+	return &negated
+}
+
+func parseVariableComparison(l *lexer) (Predicate, error) {
+	variable := l.token
+	if variable.kind != tokenWord || !variablePattern.MatchString(variable.text) {
+		return nil, l.errorf("expected a variable instead of %s", variable)
+	}
+	comparison := &VariableComparison{Variable: variable.text}
+	l.next()
+
+	op := l.token
+	if op.kind != tokenOp || op.text == "~" || op.text == "!~" {
+		return nil, l.errorf("expected one of = != > >= < <= after %s instead of %s", comparison.Variable, op)
+	}
+	comparison.Op = op.text
+	l.next()
+
+	value, err := strconv.ParseFloat(l.token.text, 64)
+	if l.token.kind != tokenWord || err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, l.errorf("expected a number instead of %s", l.token)
+	}
+	comparison.Value = value
+	l.next()
+	return comparison, nil
+}
@@ -0,0 +1,63 @@
+package filterexpr
+
+import (
+	"bigtable_api/entity"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhere(t *testing.T) {
+	frost := entity.Payload{LonLat: []float64{-50.6, -17.7}, Variables: map[string]float64{"temperatureMin": 1.5, "rain": 0}}
+	rainy := entity.Payload{Variables: map[string]float64{"temperatureMin": 18, "rain": 2.4}}
+	for _, test := range []struct {
+		where        string
+		frost, rainy bool
+	}{
+		{`rain > 0`, false, true},
+		{`rain > 0 OR temperatureMin < 2`, true, true},
+		{`rain >= 0 and temperatureMin <= 1.5`, true, false},
+		{`NOT rain = 0`, false, true},
+		{`lat < -10 AND lon > -51`, true, false},
+		// missing variables never match
+		{`humidityInst < 100`, false, false},
+		{`NOT humidityInst < 100`, false, false},
+		{`NOT (rain > 1 OR temperatureMin > 10)`, true, false},
+		{`(rain > 1 OR temperatureMin < 2) AND temperatureMin != 18`, true, false},
+		{`rain = 2.4e0`, false, true},
+	} {
+		predicate, err := ParseWhere(test.where)
+		if assert.NoError(t, err, test.where) {
+			assert.Equal(t, test.frost, predicate.Match(frost), "%s on frost", test.where)
+			assert.Equal(t, test.rainy, predicate.Match(rainy), "%s on rainy", test.where)
+		}
+	}
+}
+
+func TestWhereString(t *testing.T) {
+	predicate, err := ParseWhere(`NOT (rain > 0 AND temperatureMin < 2) AND lat = -17.5`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `(rain <= 0 OR temperatureMin >= 2) AND lat = -17.5`, predicate.String())
+	}
+}
+
+func TestWhereSyntaxErrors(t *testing.T) {
+	for where, offset := range map[string]int{
+		`rain`:              4,
+		`rain ~ 1`:          5,
+		`rain > "0"`:        7,
+		`rain > NaN`:        7,
+		`rain > 1e400`:      7,
+		`weather.rain > 0`:  0,
+		`rain > 0 AND`:      12,
+		`(rain > 0`:         9,
+		`rain > 0 rain < 1`: 9,
+	} {
+		_, err := ParseWhere(where)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), where) {
+			assert.Equal(t, offset, syntaxErr.Offset, "%s: %v", where, err)
+		}
+	}
+}
@@ -7,5 +7,13 @@ import (
 
 type ClimateGateway interface {
 	ReadPrefix(ctx context.Context, table, prefix string, filters map[string]string) ([]entity.BigtableOutput, error)
-	ReadRows(ctx context.Context, table string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error)
+	// ReadRows reads the keys of the areas at one date, or in a range of two
+	// dates where an empty date leaves that end open.
+	ReadRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error)
+	// StreamPrefix and StreamRows read like ReadPrefix and ReadRows, calling fn
+	// for each cell as it arrives until fn returns false.
+	StreamPrefix(ctx context.Context, table, prefix string, filters map[string]string, fn func(entity.BigtableOutput) bool) error
+	StreamRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string, fn func(entity.BigtableOutput) bool) error
+	// Write stores the cells, failing when any of them was not written.
+	Write(ctx context.Context, table string, inputs []entity.BigtableInput) error
 }
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/logging"
+	"bigtable_api/meteo"
+	"bigtable_api/ratelimit"
+	"bigtable_api/usecase"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAgroDays bounds the days accumulated by the agronomic indices.
+const MaxAgroDays = 366
+
+// Defaults of the growing degree days, those of maize, of the height of the
+// wind sensors, in meters, and of the soil water capacity, in mm.
+const (
+	defaultGDDBase    = 10
+	defaultGDDCap     = 30
+	defaultWindHeight = 10
+	defaultCapacity   = 100.0
+)
+
+// AgroRequest holds the parameters shared by the /agro indices, which are
+// accumulated from the observations of the areas between two local days.
+type AgroRequest struct {
+	AreaIDs []string
+	// From and To are the first and the last days, both accumulated.
+	From, To string
+	TZ       string
+}
+
+func (r *AgroRequest) validate() []Violation {
+	from, fromErr := time.Parse("2006-01-02", r.From)
+	to, toErr := time.Parse("2006-01-02", r.To)
+	if fromErr != nil || toErr != nil {
+		return nil
+	}
+	switch {
+	case to.Before(from):
+		return []Violation{{Field: "to", Rule: "gtefield", Message: "to must not be before from"}}
+	case to.Sub(from) >= MaxAgroDays*24*time.Hour:
+		return []Violation{{Field: "to", Rule: "max_days", Message: fmt.Sprintf("from and to can be at most %d days apart", MaxAgroDays)}}
+	}
+	return nil
+}
+
+func (r *AgroRequest) location() *time.Location {
+	return (&ReadClimateRequest{TZ: r.TZ}).location()
+}
+
+// keyDates converts the days to the range of keys they cover.
+func (r *AgroRequest) keyDates() ([]string, error) {
+	loc := r.location()
+	first, err := dayRange(r.From, loc)
+	if err != nil {
+		return nil, err
+	}
+	last, err := dayRange(r.To, loc)
+	if err != nil {
+		return nil, err
+	}
+	return []string{first[0], last[1]}, nil
+}
+
+// GDDRequest holds the query parameters of /agro/gdd.
+type GDDRequest struct {
+	AreaIDs []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	From    string   `form:"from" binding:"required,datetime=2006-01-02"`
+	To      string   `form:"to" binding:"required,datetime=2006-01-02"`
+	TZ      string   `form:"tz" binding:"omitempty,timezone"`
+	// Base and Cap are the temperatures, in degC, between which the crop
+	// develops, 10 and 30 by default.
+	Base   *float64 `form:"base" binding:"omitempty,min=-10,max=40"`
+	Cap    *float64 `form:"cap" binding:"omitempty,min=0,max=50"`
+	Method string   `form:"method" binding:"omitempty,oneof=average triangle"`
+}
+
+func (r *GDDRequest) agroRequest() *AgroRequest {
+	return &AgroRequest{AreaIDs: r.AreaIDs, From: r.From, To: r.To, TZ: r.TZ}
+}
+
+func (r *GDDRequest) options() usecase.GDDOptions {
+	options := usecase.GDDOptions{Base: defaultGDDBase, Cap: defaultGDDCap, Method: meteo.GDDAverage}
+	if r.Base != nil {
+		options.Base = *r.Base
+	}
+	if r.Cap != nil {
+		options.Cap = *r.Cap
+	}
+	if r.Method != "" {
+		options.Method = r.Method
+	}
+	return options
+}
+
+func (r *GDDRequest) validate() []Violation {
+	violations := r.agroRequest().validate()
+	if options := r.options(); options.Base >= options.Cap {
+		violations = append(violations, Violation{Field: "cap", Rule: "gtfield", Message: fmt.Sprintf("cap %v must be above base %v", options.Cap, options.Base)})
+	}
+	return violations
+}
+
+// ChillRequest holds the query parameters of /agro/chill.
+type ChillRequest struct {
+	AreaIDs []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	From    string   `form:"from" binding:"required,datetime=2006-01-02"`
+	To      string   `form:"to" binding:"required,datetime=2006-01-02"`
+	TZ      string   `form:"tz" binding:"omitempty,timezone"`
+	// Model is utah, for the Utah chill units, or hours, utah by default.
+	Model string `form:"model" binding:"omitempty,oneof=hours utah"`
+}
+
+func (r *ChillRequest) agroRequest() *AgroRequest {
+	return &AgroRequest{AreaIDs: r.AreaIDs, From: r.From, To: r.To, TZ: r.TZ}
+}
+
+func (r *ChillRequest) validate() []Violation {
+	return r.agroRequest().validate()
+}
+
+// ET0Request holds the query parameters of /agro/et0.
+type ET0Request struct {
+	AreaIDs []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	From    string   `form:"from" binding:"required,datetime=2006-01-02"`
+	To      string   `form:"to" binding:"required,datetime=2006-01-02"`
+	TZ      string   `form:"tz" binding:"omitempty,timezone"`
+	// WindHeight is the height, in meters, of the wind sensors, 10 by default.
+	WindHeight *float64 `form:"wind_height" binding:"omitempty,min=1,max=100"`
+}
+
+func (r *ET0Request) agroRequest() *AgroRequest {
+	return &AgroRequest{AreaIDs: r.AreaIDs, From: r.From, To: r.To, TZ: r.TZ}
+}
+
+func (r *ET0Request) validate() []Violation {
+	return r.agroRequest().validate()
+}
+
+// WaterBalanceRequest holds the query parameters of /agro/water-balance.
+type WaterBalanceRequest struct {
+	AreaIDs    []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	From       string   `form:"from" binding:"required,datetime=2006-01-02"`
+	To         string   `form:"to" binding:"required,datetime=2006-01-02"`
+	TZ         string   `form:"tz" binding:"omitempty,timezone"`
+	WindHeight *float64 `form:"wind_height" binding:"omitempty,min=1,max=100"`
+	// Capacity is the water, in mm, the soil holds, 100 by default, and
+	// Initial the water it holds before the first day, full by default.
+	Capacity *float64 `form:"capacity" binding:"omitempty,min=1,max=1000"`
+	Initial  *float64 `form:"initial" binding:"omitempty,min=0,max=1000"`
+}
+
+func (r *WaterBalanceRequest) agroRequest() *AgroRequest {
+	return &AgroRequest{AreaIDs: r.AreaIDs, From: r.From, To: r.To, TZ: r.TZ}
+}
+
+// water returns the capacity and the initial water of the soil.
+func (r *WaterBalanceRequest) water() (float64, float64) {
+	capacity := defaultCapacity
+	if r.Capacity != nil {
+		capacity = *r.Capacity
+	}
+	initial := capacity
+	if r.Initial != nil {
+		initial = *r.Initial
+	}
+	return capacity, initial
+}
+
+func (r *WaterBalanceRequest) validate() []Violation {
+	violations := r.agroRequest().validate()
+	if capacity, initial := r.water(); initial > capacity {
+		violations = append(violations, Violation{Field: "initial", Rule: "ltefield", Message: fmt.Sprintf("initial %v must not be above capacity %v", initial, capacity)})
+	}
+	return violations
+}
+
+// GrowingDegreeDays answers the growing degree days of the areas, daily and
+// accumulated.
+func (h *ClimateHandler) GrowingDegreeDays(ctx *gin.Context) {
+	var req GDDRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery), err)
+		return
+	}
+	h.agro(ctx, req.agroRequest(), func(dates []string, loc *time.Location) (interface{}, int, error) {
+		return agroSeries(h.usecase.GrowingDegreeDays(ctx, "climate_data", req.AreaIDs, dates, loc, req.options()))
+	})
+}
+
+// Chill answers the chill of the areas, daily and accumulated.
+func (h *ClimateHandler) Chill(ctx *gin.Context) {
+	var req ChillRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery), err)
+		return
+	}
+	model := req.Model
+	if model == "" {
+		model = usecase.ChillUtah
+	}
+	h.agro(ctx, req.agroRequest(), func(dates []string, loc *time.Location) (interface{}, int, error) {
+		return agroSeries(h.usecase.Chill(ctx, "climate_data", req.AreaIDs, dates, loc, model))
+	})
+}
+
+// ReferenceEvapotranspiration answers the reference evapotranspiration of the
+// areas, daily and accumulated.
+func (h *ClimateHandler) ReferenceEvapotranspiration(ctx *gin.Context) {
+	var req ET0Request
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery), err)
+		return
+	}
+	h.agro(ctx, req.agroRequest(), func(dates []string, loc *time.Location) (interface{}, int, error) {
+		return agroSeries(h.usecase.ReferenceEvapotranspiration(ctx, "climate_data", req.AreaIDs, dates, loc, h.et0Options(req.WindHeight)))
+	})
+}
+
+// WaterBalance answers the soil water balance of the areas, day by day.
+func (h *ClimateHandler) WaterBalance(ctx *gin.Context) {
+	var req WaterBalanceRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery), err)
+		return
+	}
+	options := usecase.WaterBalanceOptions{ET0Options: h.et0Options(req.WindHeight)}
+	options.Capacity, options.Initial = req.water()
+	h.agro(ctx, req.agroRequest(), func(dates []string, loc *time.Location) (interface{}, int, error) {
+		balances, err := h.usecase.WaterBalance(ctx, "climate_data", req.AreaIDs, dates, loc, options)
+		days := 0
+		for _, balance := range balances {
+			days += len(balance.Days)
+		}
+		return balances, days, err
+	})
+}
+
+func (h *ClimateHandler) et0Options(windHeight *float64) usecase.ET0Options {
+	options := usecase.ET0Options{WindHeight: defaultWindHeight, Stations: h.Stations}
+	if windHeight != nil {
+		options.WindHeight = *windHeight
+	}
+	return options
+}
+
+// agroSeries returns the series of an index with the number of their days.
+func agroSeries(series []entity.AgroSeries, err error) (interface{}, int, error) {
+	days := 0
+	for _, s := range series {
+		days += len(s.Days)
+	}
+	return series, days, err
+}
+
+// agro authorizes the read of the observations of req and answers the result
+// computed by index, with the number of days it holds.
+func (h *ClimateHandler) agro(ctx *gin.Context, req *AgroRequest, index func(dates []string, loc *time.Location) (interface{}, int, error)) {
+	start := time.Now()
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
+
+	dates, err := req.keyDates()
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	principal := auth.PrincipalFrom(ctx)
+	if err := principal.Authorize("w", req.AreaIDs); err != nil {
+		logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+
+	result, days, err := index(dates, req.location())
+	if err != nil {
+		logger.Error("error computing agronomic index", "areas", req.AreaIDs, "dates", dates, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	ratelimit.RecordRows(ctx, days)
+	logger.Info("Request successful", "areas", len(req.AreaIDs), "days", days, "duration", time.Since(start))
+	ctx.JSON(http.StatusOK, gin.H{"result": result, "status": "success"})
+}
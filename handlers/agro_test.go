@@ -0,0 +1,274 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/stations"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type AgroHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestAgroHandlersSuite(t *testing.T) {
+	suite.Run(t, new(AgroHandlersSuite))
+}
+
+func (a *AgroHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	lonlat := [2]float64{-50.59667, -17.749189}
+	created := time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC)
+	hours := map[string]struct {
+		instant     float64
+		extremes    [2]float64
+		hasExtremes bool
+	}{
+		"2023-07-01 03:00:00": {4, [2]float64{3, 5}, true},
+		"2023-07-01 09:00:00": {10, [2]float64{}, false},
+		"2023-07-01 18:00:00": {24, [2]float64{22, 26}, true},
+		"2023-07-02 03:00:00": {1, [2]float64{0, 2}, true},
+		"2023-07-02 18:00:00": {17, [2]float64{16, 18}, true},
+	}
+	// the other hours are at 13 degC, which adds no chill
+	for _, date := range []string{"2023-07-01", "2023-07-02"} {
+		for hour := 0; hour < 24; hour++ {
+			key := fmt.Sprintf("%s %02d:00:00", date, hour)
+			variables := map[string]float64{"temperatureInst": 13}
+			if reading, ok := hours[key]; ok {
+				variables["temperatureInst"] = reading.instant
+				if reading.hasExtremes {
+					variables["temperatureMin"], variables["temperatureMax"] = reading.extremes[0], reading.extremes[1]
+				}
+			}
+			gateway.add("w/A327734/"+key, created, lonlat, variables)
+		}
+	}
+	// a day with two hours is left out
+	gateway.add("w/A327734/2023-07-03 12:00:00", created, lonlat, map[string]float64{"temperatureInst": 30})
+	gateway.add("w/A327734/2023-07-03 13:00:00", created, lonlat, map[string]float64{"temperatureInst": 30})
+	// an older version, which is not accumulated
+	gateway.add("w/A327734/2023-07-01 03:00:00", created.Add(-time.Hour), lonlat, map[string]float64{"temperatureInst": -20})
+
+	// example 18 of FAO-56, Brussels on the 6th of July: from 12.3 degC and 84%
+	// at 04:00 to 21.5 degC and 63% at 14:00, a wind of 10 km/h at 10 m and
+	// 22.07 MJ m-2 of radiation
+	brussels := [2]float64{4.35, 50.8}
+	irradiation := []float64{0, 0, 0, 0, 10, 56, 163, 288, 412, 522, 609, 664, 684, 664, 609, 522, 412, 288, 163, 55, 9.6, 0, 0, 0}
+	for hour := 0; hour < 24; hour++ {
+		warming := float64((hour+24-14)%24) / 14
+		if hour >= 4 && hour <= 14 {
+			warming = float64(14-hour) / 10
+		}
+		variables := map[string]float64{
+			"temperatureInst":  21.5 - 9.2*warming,
+			"humidityInst":     63 + 21*warming,
+			"windSpeedInst":    10 / 3.6,
+			"solarIrradiation": irradiation[hour],
+		}
+		if hour == 5 {
+			variables["rain"] = 30
+		}
+		gateway.add(fmt.Sprintf("w/A327736/2023-07-06 %02d:00:00", hour), created, brussels, variables)
+		// a day with two hours is left out
+		if hour == 12 || hour == 13 {
+			gateway.add(fmt.Sprintf("w/A327736/2023-07-08 %02d:00:00", hour), created, brussels, variables)
+		}
+		// the readings of a faulty sensor are dropped
+		faulty := map[string]float64{"temperatureInst": 886.23, "atmosphericPressureInst": 583.41}
+		if hour != 3 {
+			faulty = variables
+		}
+		gateway.add(fmt.Sprintf("w/A327736/2023-07-09 %02d:00:00", hour), created, brussels, faulty)
+		// a rainy day whose radiation sensor stopped at noon
+		cloudy := map[string]float64{}
+		for name, value := range variables {
+			if name != "solarIrradiation" || hour < 12 {
+				cloudy[name] = value
+			}
+		}
+		gateway.add(fmt.Sprintf("w/A327736/2023-07-10 %02d:00:00", hour), created, brussels, cloudy)
+		// a day without wind has no evapotranspiration
+		delete(variables, "windSpeedInst")
+		gateway.add(fmt.Sprintf("w/A327736/2023-07-07 %02d:00:00", hour), created, brussels, variables)
+	}
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(gateway))
+	catalog, err := stations.NewCatalog([]stations.Station{{AreaID: "A327736", Lon: 4.35, Lat: 50.8, Elevation: 100}})
+	a.Nil(err)
+	climateHandler.Stations = catalog
+	a.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+type agroOutput struct {
+	Result []entity.AgroSeries `json:"result"`
+	Status string              `json:"status"`
+}
+
+func (a *AgroHandlersSuite) get(path string, query url.Values) (int, agroOutput) {
+	req, err := http.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil)
+	a.Nil(err)
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	var out agroOutput
+	a.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (a *AgroHandlersSuite) TestGrowingDegreeDays() {
+	code, out := a.get("/agro/gdd", url.Values{"area_id": {"A327734,A327735"}, "from": {"2023-07-01"}, "to": {"2023-07-03"}})
+	a.Equal(http.StatusOK, code)
+	a.Len(out.Result, 2)
+
+	gdd := out.Result[0]
+	a.Equal("gdd", gdd.Index)
+	a.Len(gdd.Days, 2)
+	// from 3 to 26 and from 0 to 18 degC
+	first, second := gdd.Days[0], gdd.Days[1]
+	a.Equal("2023-07-01", first.Date)
+	a.Equal(24, first.Hours)
+	a.Equal(3.0, *first.TemperatureMin)
+	a.Equal(26.0, *first.TemperatureMax)
+	a.Equal(4.5, first.Value)
+	a.Equal(0.0, second.Value)
+	a.Equal(4.5, second.Cumulative)
+	a.Equal(4.5, gdd.Total)
+
+	// an area without observations has an empty series
+	a.Equal("A327735", out.Result[1].AreaID)
+	a.Empty(out.Result[1].Days)
+
+	code, out = a.get("/agro/gdd", url.Values{"area_id": {"A327734"}, "from": {"2023-07-01"}, "to": {"2023-07-01"}, "base": {"0"}, "cap": {"20"}, "method": {"triangle"}})
+	a.Equal(http.StatusOK, code)
+	a.Len(out.Result[0].Days, 1)
+	// the mean of 14.5, less 6/23 of the day above 20, 3 degrees above on average
+	a.InDelta(14.5-6.0/23*3, out.Result[0].Days[0].Value, 0.005)
+}
+
+func (a *AgroHandlersSuite) TestChill() {
+	code, out := a.get("/agro/chill", url.Values{"area_id": {"A327734"}, "from": {"2023-07-01"}, "to": {"2023-07-02"}})
+	a.Equal(http.StatusOK, code)
+	series := out.Result[0]
+	a.Equal("utah_chill_units", series.Index)
+	// 4, 10 and 24 degC, then 1 and 17 degC, with 13 degC the rest of the day
+	a.Equal(1+0.5-1, series.Days[0].Value)
+	a.Equal(0-0.5, series.Days[1].Value)
+	a.Equal(0.0, series.Total)
+
+	// in Sao Paulo 03:00 UTC is the first hour of the day, and the three
+	// hours of the 30th of June are left out
+	code, out = a.get("/agro/chill", url.Values{"area_id": {"A327734"}, "from": {"2023-06-30"}, "to": {"2023-07-02"}, "model": {"hours"}, "tz": {"America/Sao_Paulo"}})
+	a.Equal(http.StatusOK, code)
+	series = out.Result[0]
+	a.Len(series.Days, 2)
+	a.Equal("2023-07-01", series.Days[0].Date)
+	a.Equal(1.0, series.Days[0].Value)
+	a.Equal(2.0, series.Total)
+}
+
+func (a *AgroHandlersSuite) TestReferenceEvapotranspiration() {
+	code, out := a.get("/agro/et0", url.Values{"area_id": {"A327736"}, "from": {"2023-07-06"}, "to": {"2023-07-07"}})
+	a.Equal(http.StatusOK, code)
+	series := out.Result[0]
+	a.Equal("et0", series.Index)
+	a.Len(series.Days, 1)
+	a.Equal(24, series.Days[0].Hours)
+	a.InDelta(12.3, *series.Days[0].TemperatureMin, 1e-9)
+	a.InDelta(21.5, *series.Days[0].TemperatureMax, 1e-9)
+	// 3.9 mm in the example
+	a.InDelta(3.9, series.Days[0].Value, 0.05)
+	a.Equal(series.Days[0].Value, series.Total)
+
+	code, out = a.get("/agro/et0", url.Values{"area_id": {"A327736"}, "from": {"2023-07-06"}, "to": {"2023-07-09"}})
+	a.Equal(http.StatusOK, code)
+	series = out.Result[0]
+	a.Len(series.Days, 2)
+	a.Equal("2023-07-09", series.Days[1].Date)
+	a.InDelta(21.5, *series.Days[1].TemperatureMax, 1e-9)
+	a.InDelta(series.Days[0].Value, series.Days[1].Value, 0.05)
+}
+
+func (a *AgroHandlersSuite) TestWaterBalance() {
+	req, err := http.NewRequest(http.MethodGet, "/agro/water-balance?"+url.Values{"area_id": {"A327736"}, "from": {"2023-07-06"}, "to": {"2023-07-07"}, "capacity": {"50"}, "initial": {"40"}}.Encode(), nil)
+	a.Nil(err)
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	a.Equal(http.StatusOK, w.Code)
+	var out struct {
+		Result []entity.WaterBalance `json:"result"`
+	}
+	a.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	balance := out.Result[0]
+	a.Equal(50.0, balance.Capacity)
+	a.Len(balance.Days, 2)
+
+	// the rain fills the soil, and what is left of it drains
+	day := balance.Days[0]
+	a.Equal(30.0, day.Rain)
+	a.Equal(50.0, day.Storage)
+	a.Equal(0.0, day.Deficit)
+	a.InDelta(40+30-50-*day.ET0, day.Drainage, 0.01)
+	// the rain of the day without wind drains too
+	windless := balance.Days[1]
+	a.Nil(windless.ET0)
+	a.Equal(30.0, windless.Drainage)
+	a.InDelta(day.Drainage+30, balance.Drainage, 0.01)
+	a.Equal(*day.ET0, balance.ET0)
+	a.Equal(60.0, balance.Rain)
+
+	code, _ := a.get("/agro/water-balance", url.Values{"area_id": {"A327736"}, "from": {"2023-07-06"}, "to": {"2023-07-07"}, "capacity": {"50"}, "initial": {"60"}})
+	a.Equal(http.StatusBadRequest, code)
+}
+
+func (a *AgroHandlersSuite) TestWaterBalanceIncompleteDay() {
+	req, err := http.NewRequest(http.MethodGet, "/agro/water-balance?"+url.Values{"area_id": {"A327736"}, "from": {"2023-07-09"}, "to": {"2023-07-10"}, "capacity": {"100"}, "initial": {"10"}}.Encode(), nil)
+	a.Nil(err)
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	a.Equal(http.StatusOK, w.Code)
+	var out struct {
+		Result []entity.WaterBalance `json:"result"`
+	}
+	a.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	days := out.Result[0].Days
+	a.Len(days, 2)
+
+	a.NotNil(days[0].ET0)
+	a.InDelta(10+30-*days[0].ET0, days[0].Storage, 0.01)
+	// the rain of the day without the afternoon radiation still fills the soil
+	cloudy := days[1]
+	a.Equal("2023-07-10", cloudy.Date)
+	a.Equal(24, cloudy.Hours)
+	a.Nil(cloudy.ET0)
+	a.Equal(30.0, cloudy.Rain)
+	a.InDelta(days[0].Storage+30, cloudy.Storage, 0.01)
+	a.InDelta(100-cloudy.Storage, cloudy.Deficit, 0.01)
+}
+
+func (a *AgroHandlersSuite) TestInvalidRequests() {
+	for _, query := range []url.Values{
+		{"from": {"2023-07-01"}, "to": {"2023-07-02"}},
+		{"area_id": {"A327734"}, "from": {"2023-07-01"}},
+		{"area_id": {"A327734"}, "from": {"2023-07-01 00:00:00"}, "to": {"2023-07-02"}},
+		{"area_id": {"A327734"}, "from": {"2023-07-02"}, "to": {"2023-07-01"}},
+		{"area_id": {"A327734"}, "from": {"2022-01-01"}, "to": {"2023-07-01"}},
+		{"area_id": {"A327734"}, "from": {"2023-07-01"}, "to": {"2023-07-02"}, "base": {"30"}},
+		{"area_id": {"A327734"}, "from": {"2023-07-01"}, "to": {"2023-07-02"}, "method": {"sine"}},
+	} {
+		code, _ := a.get("/agro/gdd", query)
+		a.Equal(http.StatusBadRequest, code, query.Encode())
+	}
+	code, _ := a.get("/agro/chill", url.Values{"area_id": {"A327734"}, "from": {"2023-07-01"}, "to": {"2023-07-02"}, "model": {"dynamic"}})
+	a.Equal(http.StatusBadRequest, code)
+}
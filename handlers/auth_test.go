@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+// stubGateway answers every read with a single row, so that the
+// authorization rules can be tested without Bigtable.
+type stubGateway struct{}
+
+func (stubGateway) ReadPrefix(ctx context.Context, table, prefix string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	return []entity.BigtableOutput{{Key: prefix}}, nil
+}
+
+func (stubGateway) ReadRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	return []entity.BigtableOutput{{Key: areas[0]}}, nil
+}
+
+func (g stubGateway) StreamPrefix(ctx context.Context, table, prefix string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadPrefix(ctx, table, prefix, filters)
+	return stream(output, fn)
+}
+
+func (g stubGateway) StreamRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadRows(ctx, table, dataType, areas, dates, filters)
+	return stream(output, fn)
+}
+
+func (stubGateway) Write(ctx context.Context, table string, inputs []entity.BigtableInput) error {
+	return nil
+}
+
+func stream(output []entity.BigtableOutput, fn func(entity.BigtableOutput) bool) error {
+	for _, o := range output {
+		if !fn(o) {
+			break
+		}
+	}
+	return nil
+}
+
+type AuthHandlersSuite struct {
+	suite.Suite
+	router     *gin.Engine
+	issuer     *httptest.Server
+	signingKey *rsa.PrivateKey
+}
+
+func TestAuthHandlersSuite(t *testing.T) {
+	suite.Run(t, new(AuthHandlersSuite))
+}
+
+func (a *AuthHandlersSuite) SetupSuite() {
+	var err error
+	a.signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	a.Nil(err)
+
+	// local stand-in for the OIDC issuer jwks_uri
+	a.issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(a.signingKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(a.signingKey.E)).Bytes()),
+			}, {
+				// a key type the verifier does not support is skipped
+				"kty": "OKP",
+				"kid": "ed25519-key",
+				"use": "sig",
+				"crv": "Ed25519",
+				"x":   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+			}},
+		})
+	}))
+
+	dir := a.T().TempDir()
+	keysFile := filepath.Join(dir, "keys.json")
+	err = os.WriteFile(keysFile, []byte(`{"keys": [{"id": "forecast-only", "hash": "`+auth.HashKey("forecast-key")+`", "scopes": {"datatypes": ["f"]}}]}`), 0600)
+	a.Nil(err)
+	tenantsFile := filepath.Join(dir, "tenants.json")
+	err = os.WriteFile(tenantsFile, []byte(`{"tenants": {"farm-co": {"scopes": {"areas": ["A327734", "A327735"]}}}}`), 0600)
+	a.Nil(err)
+
+	keyStore, err := auth.NewKeyStore(keysFile)
+	a.Nil(err)
+	jwks, err := auth.NewJWKS(context.Background(), a.issuer.URL)
+	a.Nil(err)
+	tokenVerifier, err := auth.NewTokenVerifier(jwks, auth.TokenConfig{
+		Issuer:      "https://issuer.test",
+		Audience:    "bigtable-api",
+		TenantsFile: tenantsFile,
+	})
+	a.Nil(err)
+
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(stubGateway{}))
+	a.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler(), auth.Middleware(keyStore, tokenVerifier))
+}
+
+func (a *AuthHandlersSuite) TearDownSuite() {
+	a.issuer.Close()
+}
+
+func (a *AuthHandlersSuite) token(org string, expiresAt time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":    "https://issuer.test",
+		"aud":    "bigtable-api",
+		"sub":    "user-1",
+		"org_id": org,
+		"exp":    expiresAt.Unix(),
+	})
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(a.signingKey)
+	a.Nil(err)
+	return signed
+}
+
+func (a *AuthHandlersSuite) get(url string, header, value string) int {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	a.Nil(err)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (a *AuthHandlersSuite) TestUnsupportedSigningKeys() {
+	jwksFile := filepath.Join(a.T().TempDir(), "jwks.json")
+	a.Nil(os.WriteFile(jwksFile, []byte(`{"keys": [
+		{"kty": "OKP", "kid": "ed25519-key", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{"kty": "oct", "kid": "hmac-key", "k": "c2VjcmV0"}
+	]}`), 0600))
+	_, err := auth.NewJWKS(context.Background(), jwksFile)
+	a.EqualError(err, "jwks has no supported signing key")
+}
+
+func (a *AuthHandlersSuite) TestUnauthenticated() {
+	a.Equal(http.StatusUnauthorized, a.get("/read/climate-data?type=w&area_id=A327734", "", ""))
+	a.Equal(http.StatusUnauthorized, a.get("/read/climate-data?type=w&area_id=A327734", "X-API-Key", "wrong"))
+	a.Equal(http.StatusOK, a.get("/", "", ""))
+}
+
+func (a *AuthHandlersSuite) TestAPIKeyScopes() {
+	a.Equal(http.StatusOK, a.get("/read/climate-data?type=f&area_id=A327734", "X-API-Key", "forecast-key"))
+	a.Equal(http.StatusForbidden, a.get("/read/climate-data?type=w&area_id=A327734", "X-API-Key", "forecast-key"))
+}
+
+func (a *AuthHandlersSuite) TestBearerToken() {
+	bearer := "Bearer " + a.token("farm-co", time.Now().Add(time.Hour))
+
+	a.T().Run("prefix path", func(t *testing.T) {
+		a.Equal(http.StatusOK, a.get("/read/climate-data?type=w&area_id=A327734", "Authorization", bearer))
+		a.Equal(http.StatusForbidden, a.get("/read/climate-data?type=w&area_id=A100000", "Authorization", bearer))
+		a.Equal(http.StatusForbidden, a.get("/read/climate-data?type=w", "Authorization", bearer))
+	})
+
+	a.T().Run("multi-area path", func(t *testing.T) {
+		a.Equal(http.StatusOK, a.get("/read/climate-data?type=w&area_id=A327734,A327735&date=2023-10-10%2000:00:00", "Authorization", bearer))
+		a.Equal(http.StatusForbidden, a.get("/read/climate-data?type=w&area_id=A327734,A100000&date=2023-10-10%2000:00:00", "Authorization", bearer))
+	})
+
+	a.T().Run("invalid tokens", func(t *testing.T) {
+		expired := "Bearer " + a.token("farm-co", time.Now().Add(-time.Hour))
+		a.Equal(http.StatusUnauthorized, a.get("/read/climate-data?type=w&area_id=A327734", "Authorization", expired))
+		unknownOrg := "Bearer " + a.token("other-co", time.Now().Add(time.Hour))
+		a.Equal(http.StatusUnauthorized, a.get("/read/climate-data?type=w&area_id=A327734", "Authorization", unknownOrg))
+	})
+}
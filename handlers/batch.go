@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/logging"
+	"bigtable_api/ratelimit"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// MaxBatchQueries is how many queries a batch accepts.
+	MaxBatchQueries = 50
+	// batchConcurrency is how many queries of a batch are read at once.
+	batchConcurrency = 8
+)
+
+// BatchRequest is the JSON body of POST /batch, independent queries read
+// concurrently.
+type BatchRequest []BatchQuery
+
+// BatchQuery is a query of a batch, either a query of POST /query/climate-data
+// or the latest cell of areas. ID, when given, is returned with its result.
+type BatchQuery struct {
+	ID     string        `json:"id"`
+	Query  *QueryRequest `json:"query"`
+	Latest *LatestQuery  `json:"latest"`
+}
+
+// LatestQuery reads the latest cell of each area.
+type LatestQuery struct {
+	Datatype string   `json:"datatype" binding:"required,oneof=w f"`
+	Areas    []string `json:"areas" binding:"required,min=1,max=50,dive,areaid"`
+}
+
+func (l *LatestQuery) validate() []Violation {
+	return nil
+}
+
+// validate checks the size of the batch, the queries being validated one by
+// one so that an invalid query does not fail the others.
+func (b BatchRequest) validate() []Violation {
+	if len(b) == 0 {
+		return []Violation{{Field: "body", Rule: "required", Message: "a batch requires at least one query"}}
+	}
+	if len(b) > MaxBatchQueries {
+		return []Violation{{Field: "body", Rule: "max", Message: fmt.Sprintf("a batch accepts at most %d queries", MaxBatchQueries)}}
+	}
+	return nil
+}
+
+// Batch reads the queries of the body concurrently, answering their results
+// in the same order. A failed query is reported in its result, with the
+// status code it would have on its own. The queries after the first take a
+// token of the rate limit each, and their rows are counted against the daily
+// quota as they end, so that a batch stops once the quota is exhausted.
+func (h *ClimateHandler) Batch(ctx *gin.Context) {
+	start := time.Now()
+	logger := logging.FromContext(ctx)
+
+	var batch BatchRequest
+	if err := decodeJSON(ctx, &batch); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	if violations := batch.validate(); len(violations) > 0 {
+		abortValidation(ctx, logger, &ValidationError{Violations: violations})
+		return
+	}
+
+	results := make([]gin.H, len(batch))
+	rows := make([]int, len(batch))
+	semaphore := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := range batch {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			output, code, err := h.batchQuery(ctx, &batch[i], i > 0)
+			result := gin.H{"code": code}
+			if batch[i].ID != "" {
+				result["id"] = batch[i].ID
+			}
+			var validationErr *ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				result["status"], result["error"], result["violations"] = "failed", "invalid request", validationErr.Violations
+			case err != nil:
+				logger.Warn("error reading batch query", "index", i, "id", batch[i].ID, "code", code, "error", err)
+				result["status"], result["error"] = "failed", err.Error()
+			default:
+				result["status"], result["result"], result["count"] = "success", output, len(output)
+				rows[i] = len(output)
+				ratelimit.CountRows(ctx, len(output))
+			}
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	total, failed := 0, 0
+	for i := range results {
+		total += rows[i]
+		if results[i]["status"] != "success" {
+			failed++
+		}
+	}
+	logger.Info("Request successful", "queries", len(batch), "failed", failed, "rows", total, "duration", time.Since(start))
+	ctx.JSON(http.StatusOK, gin.H{"results": results, "status": "success"})
+}
+
+// batchQuery reads a query of a batch, returning the status code of its result.
+// A limited query is refused when the client has no token or quota left.
+func (h *ClimateHandler) batchQuery(ctx *gin.Context, query *BatchQuery, limited bool) ([]entity.BigtableOutput, int, error) {
+	if limited {
+		if decision := ratelimit.AllowQuery(ctx); !decision.Allowed {
+			return nil, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded, retry in %v", decision.RetryAfter)
+		}
+	}
+	switch {
+	case (query.Query == nil) == (query.Latest == nil):
+		return nil, http.StatusBadRequest, &ValidationError{Violations: []Violation{{Field: "query", Rule: "required_without", Message: "a batch query requires either query or latest"}}}
+	case query.Latest != nil:
+		return h.batchLatest(ctx, query.Latest)
+	}
+
+	if err := validateRequest(query.Query); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if query.Query.Format != "" && query.Query.Format != "json" {
+		return nil, http.StatusBadRequest, &ValidationError{Violations: []Violation{{Field: "format", Rule: "eq", Message: "a batch query can only return json"}}}
+	}
+	req, read, err := h.prepareQuery(ctx, query.Query)
+	var forbidden *forbiddenError
+	if errors.As(err, &forbidden) {
+		return nil, http.StatusForbidden, err
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	output, err := collect(req, read)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return output, http.StatusOK, nil
+}
+
+// batchLatest reads the latest cell of each area, skipping the areas without
+// data.
+func (h *ClimateHandler) batchLatest(ctx *gin.Context, query *LatestQuery) ([]entity.BigtableOutput, int, error) {
+	if err := validateRequest(query); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := auth.PrincipalFrom(ctx).Authorize(query.Datatype, query.Areas); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	output := []entity.BigtableOutput{}
+	for _, area := range query.Areas {
+		latest, found, err := h.usecase.Latest(ctx, "climate_data", query.Datatype, area)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if found {
+			output = append(output, latest)
+		}
+	}
+	return output, http.StatusOK, nil
+}
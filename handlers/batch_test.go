@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/ratelimit"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type BatchHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestBatchHandlersSuite(t *testing.T) {
+	suite.Run(t, new(BatchHandlersSuite))
+}
+
+func (b *BatchHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	lonlat := [2]float64{-50.59667, -17.749189}
+	gateway.add("f/A327735/2023-10-12 00:00:00", created, lonlat, map[string]float64{"temperatureInst": 31})
+	gateway.add("w/A327734/2023-10-10 00:00:00", created, lonlat, map[string]float64{"temperatureInst": 28})
+	gateway.add("w/A327734/2023-10-10 01:00:00", created, lonlat, map[string]float64{"temperatureInst": 26})
+	gateway.add("w/A327735/2023-10-10 00:00:00", created, lonlat, map[string]float64{"temperatureInst": 29})
+	climateUsecase := usecase.NewClimateUsecase(gateway)
+	climateUsecase.Now = func() time.Time { return created }
+	climateHandler := handlers.NewClimateHandler(climateUsecase)
+	b.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+type batchOutput struct {
+	Status  string `json:"status"`
+	Results []struct {
+		ID         string                  `json:"id"`
+		Code       int                     `json:"code"`
+		Status     string                  `json:"status"`
+		Result     []entity.BigtableOutput `json:"result"`
+		Count      int                     `json:"count"`
+		Error      string                  `json:"error"`
+		Violations []handlers.Violation    `json:"violations"`
+	} `json:"results"`
+}
+
+func (b *BatchHandlersSuite) post(body string) (int, batchOutput) {
+	req, err := http.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	b.Nil(err)
+	w := httptest.NewRecorder()
+	b.router.ServeHTTP(w, req)
+	var out batchOutput
+	b.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (b *BatchHandlersSuite) TestIndependentQueries() {
+	code, out := b.post(`[
+		{"id": "weather", "query": {"datatype": "w", "areas": ["A327734"], "time": {"at": "2023-10-10"}}},
+		{"id": "forecast", "query": {"datatype": "f", "areas": ["A327735"], "projection": ["temperatureInst"]}},
+		{"id": "latest", "latest": {"datatype": "w", "areas": ["A327734", "A327735", "A999999"]}},
+		{"id": "invalid", "query": {"datatype": "x"}},
+		{"id": "export", "query": {"datatype": "w", "format": "csv"}},
+		{"id": "empty"}
+	]`)
+	b.Equal(http.StatusOK, code)
+	b.Equal("success", out.Status)
+	b.Len(out.Results, 6)
+
+	var ids []string
+	for _, result := range out.Results {
+		ids = append(ids, result.ID)
+	}
+	b.Equal([]string{"weather", "forecast", "latest", "invalid", "export", "empty"}, ids)
+
+	b.Equal(http.StatusOK, out.Results[0].Code)
+	b.Equal(2, out.Results[0].Count)
+	b.Equal("f/A327735/2023-10-12 00:00:00", out.Results[1].Result[0].Key)
+
+	latest := out.Results[2]
+	b.Equal("success", latest.Status)
+	b.Len(latest.Result, 2)
+	b.Equal("w/A327734/2023-10-10 01:00:00", latest.Result[0].Key)
+	b.Equal("w/A327735/2023-10-10 00:00:00", latest.Result[1].Key)
+
+	for _, failed := range out.Results[3:] {
+		b.Equal("failed", failed.Status, failed.ID)
+		b.Equal(http.StatusBadRequest, failed.Code, failed.ID)
+		b.NotEmpty(failed.Violations, failed.ID)
+	}
+	b.Equal("datatype", out.Results[3].Violations[0].Field)
+}
+
+func (b *BatchHandlersSuite) TestRateLimit() {
+	gateway := &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	gateway.add("w/A327734/2023-10-10 00:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 28})
+	climateUsecase := usecase.NewClimateUsecase(gateway)
+	climateUsecase.Now = func() time.Time { return created }
+	limiter := ratelimit.NewLimiter(ratelimit.Config{Default: ratelimit.Tier{RequestsPerSecond: 0.001, Burst: 3}})
+	b.router = router.InitializeRouter(handlers.NewClimateHandler(climateUsecase), nil, handlers.NewHealthHandler(), ratelimit.Middleware(limiter))
+
+	// the batch takes the first token, and two of its other queries the rest
+	query := `{"latest": {"datatype": "w", "areas": ["A327734"]}}`
+	code, out := b.post("[" + strings.Repeat(query+",", 4) + query + "]")
+	b.Equal(http.StatusOK, code)
+	codes := map[int]int{}
+	for _, result := range out.Results {
+		codes[result.Code]++
+	}
+	b.Equal(map[int]int{http.StatusOK: 3, http.StatusTooManyRequests: 2}, codes)
+	b.Equal(http.StatusOK, out.Results[0].Code)
+}
+
+func (b *BatchHandlersSuite) TestInvalidBatch() {
+	many := make([]string, handlers.MaxBatchQueries+1)
+	for i := range many {
+		many[i] = `{"latest": {"datatype": "w", "areas": ["A327734"]}}`
+	}
+	for _, body := range []string{`[]`, `{"queries": []}`, "[" + strings.Join(many, ",") + "]"} {
+		code, out := b.post(body)
+		b.Equal(http.StatusBadRequest, code)
+		b.Empty(out.Results)
+	}
+}
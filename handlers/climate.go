@@ -1,11 +1,20 @@
 package handlers
 
 import (
+	"bigtable_api/auth"
 	"bigtable_api/entity"
+	"bigtable_api/export"
+	"bigtable_api/logging"
+	"bigtable_api/meteo"
+	"bigtable_api/ratelimit"
+	"bigtable_api/stations"
+	"bigtable_api/tracing"
 	"bigtable_api/usecase"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +22,8 @@ import (
 
 type ClimateHandler struct {
 	usecase *usecase.ClimateUsecase
+	// Stations give the elevation of the areas to the evapotranspiration.
+	Stations *stations.Catalog
 }
 
 func NewClimateHandler(climateUsecase *usecase.ClimateUsecase) *ClimateHandler {
@@ -21,90 +32,237 @@ func NewClimateHandler(climateUsecase *usecase.ClimateUsecase) *ClimateHandler {
 
 func (h *ClimateHandler) ReadClimateData(ctx *gin.Context) {
 	start := time.Now()
-	dataType := ctx.Query("type")
-	areaID := ctx.Query("area_id")
-	date := ctx.Query("date")
-	version := ctx.Query("version")
-	regexp := ctx.Query("regexp")
-	count := ctx.Query("count")
-
-	log.Println(dataType, areaID)
-
-	var prefixes []string
-	if dataType == "" {
-		log.Printf("error reading prefix. No datatype provided")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": "no datatype provided"})
-		return
-	}
-	prefixes = append(prefixes, dataType)
-
-	var areas, dates []string
-
-	if areaID != "" {
-		areas = strings.Split(areaID, ",")
-	}
-
-	if date != "" {
-		dates = strings.Split(date, ",")
-	}
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
 
-	if len(areas) == 0 && len(dates) > 0 {
-		log.Printf("error reading prefix. Missing area_id")
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": "missing area_id"})
+	var req ReadClimateRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logger, err)
 		return
 	}
-
-	if len(areas) == 1 {
-		prefixes = append(prefixes, areas[0])
-		if len(dates) == 1 {
-			prefixes = append(prefixes, dates[0])
-		}
+	dates, err := req.keyDates()
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
 	}
+	dataType, areas := req.Type, req.AreaIDs
 
-	filters := make(map[string]string)
-	if version != "" {
-		filters["version"] = version
+	principal := auth.PrincipalFrom(ctx)
+	if err := principal.Authorize(dataType, areas); err != nil {
+		logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+		return
 	}
+	prefixes := req.prefixes(dates, principal)
+	filters := req.filters()
 
-	if regexp != "" {
-		filters["regexp"] = regexp
+	if format := req.format(ctx); format != "json" {
+		h.writeExport(ctx, logger, format, &req, req.exportVariables(), h.reader(ctx, &req, dates, principal))
+		return
 	}
 
 	var output []entity.BigtableOutput
-	var err error
-
-	if len(areas) > 1 || len(dates) > 1 {
-		for _, date := range dates {
-			layout := "2006-01-02 15:04:05"
-			if _, err := time.Parse(layout, date); err != nil {
-				log.Printf("error reading data: incomplete date")
-				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": "incomplete date"})
-				return
-			}
+
+	if req.transformsCells() {
+		output, err = collect(&req, h.reader(ctx, &req, dates, principal))
+		if err != nil {
+			logger.Error("error reading", "areas", areas, "dates", dates, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+			return
 		}
-		output, err = h.usecase.Read(ctx, "climate_data", filters, areas, dates)
+	} else if len(areas) > 1 || len(dates) > 1 {
+		output, err = h.usecase.Read(ctx, "climate_data", dataType, filters, areas, dates)
 		if err != nil {
-			log.Printf("error reading areas: %s and dates: %s. Error: %v", areas, dates, err)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err})
+			logger.Error("error reading areas", "areas", areas, "dates", dates, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
 			return
 		}
 	} else {
 		output, err = h.usecase.ReadPrefix(ctx, "climate_data", filters, prefixes...)
 		if err != nil {
-			log.Printf("error reading prefix %s/%s. Error: %v", dataType, areaID, err)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err})
+			logger.Error("error reading prefix", "prefixes", prefixes, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
 			return
 		}
 	}
 
+	if req.TZ != "" && !req.transformsCells() {
+		localize(output, req.location())
+	}
+
 	result := make(map[string]interface{})
 	result["result"] = output
 
-	if count == "true" {
+	if req.Count == "true" {
 		result["count"] = len(output)
 	}
 	result["status"] = "success"
+	ratelimit.RecordRows(ctx, len(output))
 
-	log.Printf("Request successful. Datatype: %s, areas: %s, dates: %s Time taken: %v.", dataType, areaID, date, time.Since(start))
+	logger.Info("Request successful", "rows", len(output), "duration", time.Since(start))
+	_, span := tracing.Start(ctx, "encode response")
 	ctx.JSON(http.StatusOK, result)
+	span.End()
+}
+
+// reader returns the streaming read of req, by the keys of its areas and dates
+// or, for a single one, by prefix.
+func (h *ClimateHandler) reader(ctx context.Context, req *ReadClimateRequest, dates []string, principal *auth.Principal) func(func(entity.BigtableOutput) bool) error {
+	filters := req.filters()
+	read := func(fn func(entity.BigtableOutput) bool) error {
+		if len(req.AreaIDs) > 1 || len(dates) > 1 {
+			return h.usecase.Stream(ctx, "climate_data", req.Type, filters, req.AreaIDs, dates, fn)
+		}
+		return h.usecase.StreamPrefix(ctx, "climate_data", filters, fn, req.prefixes(dates, principal)...)
+	}
+	if len(req.Derived) > 0 {
+		read = derive(read, req.Derived)
+	}
+	if !req.selectsIssuance() {
+		return read
+	}
+	return func(fn func(entity.BigtableOutput) bool) error {
+		emit := func(output entity.BigtableOutput) bool {
+			if lead, ok := usecase.LeadTime(output); ok {
+				hours := lead.Hours()
+				output.LeadTimeHours = &hours
+			}
+			return fn(output)
+		}
+		if req.LeadTime != "" {
+			lead, _ := parseLeadTime(req.LeadTime)
+			return read(usecase.IssuedBefore(lead, emit))
+		}
+		if req.Evolution != "true" {
+			return read(emit)
+		}
+		// the versions of a single key, read newest first, are few
+		var versions []entity.BigtableOutput
+		err := read(func(output entity.BigtableOutput) bool {
+			versions = append(versions, output)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		for i := len(versions) - 1; i >= 0; i-- {
+			if !emit(versions[i]) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+// derive adds the derived variables to the payloads of the cells of read.
+func derive(read func(func(entity.BigtableOutput) bool) error, names []string) func(func(entity.BigtableOutput) bool) error {
+	return func(fn func(entity.BigtableOutput) bool) error {
+		return read(func(output entity.BigtableOutput) bool {
+			if value, err := meteo.AddDerived(output.Value, names); err == nil {
+				output.Value = value
+			}
+			return fn(output)
+		})
+	}
+}
+
+// exportFlushRows is how many rows are buffered before being sent to the client.
+const exportFlushRows = 1000
+
+// MaxNetCDFCells bounds the cells of a NetCDF export, which is built in memory
+// before being sent, about a year of hourly keys of 20 areas.
+const MaxNetCDFCells = 200000
+
+// writeExport streams the cells read by stream into a file, so that large
+// scans are not held in memory, with a column per variable for the formats
+// that need them before the first row. NetCDF files are the exception, held
+// in memory up to MaxNetCDFCells cells. Errors after the file started being
+// sent can only truncate it.
+func (h *ClimateHandler) writeExport(ctx *gin.Context, logger *slog.Logger, format string, req *ReadClimateRequest, variables []string, stream func(func(entity.BigtableOutput) bool) error) {
+	start := time.Now()
+	var writer export.Writer
+	extension := format
+	switch format {
+	case "csv":
+		options := export.CSVOptions{Location: req.location(), Variables: variables}
+		if req.Delimiter != "" {
+			options.Delimiter = []rune(req.Delimiter)[0]
+		}
+		if req.DecimalSeparator != "" {
+			options.DecimalSeparator = []rune(req.DecimalSeparator)[0]
+		}
+		writer = export.NewCSVWriter(ctx.Writer, options)
+		ctx.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	case "parquet":
+		writer = export.NewParquetWriter(ctx.Writer, variables)
+		ctx.Header("Content-Type", mimeParquet)
+	case "netcdf":
+		writer = export.NewNetCDFWriter(ctx.Writer)
+		ctx.Header("Content-Type", mimeNetCDF)
+		extension = "nc"
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="climate-data.`+extension+`"`)
+
+	rows := 0
+	var writeErr error
+	err := stream(func(output entity.BigtableOutput) bool {
+		if format == "netcdf" && rows == MaxNetCDFCells {
+			writeErr = fmt.Errorf("netcdf exports hold at most %d cells, read fewer areas or dates", MaxNetCDFCells)
+			return false
+		}
+		if writeErr = writer.Write(output); writeErr != nil {
+			return false
+		}
+		rows++
+		// a NetCDF file is only sent on Close, and flushing would send its status
+		if rows%exportFlushRows == 0 && format != "netcdf" {
+			writeErr = writer.Flush()
+			ctx.Writer.Flush()
+		}
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	ratelimit.RecordRows(ctx, rows)
+	if err != nil && !ctx.Writer.Written() {
+		logger.Error("error reading export", "format", format, "error", err)
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error("error streaming export, the file is truncated", "format", format, "rows", rows, "error", err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		logger.Error("error writing export", "format", format, "error", err)
+		return
+	}
+	logger.Info("Request successful", "format", format, "rows", rows, "duration", time.Since(start))
+}
+
+// abortValidation answers 400 with every violation of the request.
+func abortValidation(ctx *gin.Context, logger *slog.Logger, err error) {
+	logger.Warn("invalid request", "error", err)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"status":     "failed",
+		"error":      "invalid request",
+		"violations": validationErr.Violations,
+	})
+}
+
+// localize shows the creation time and the date of the keys in loc.
+func localize(output []entity.BigtableOutput, loc *time.Location) {
+	for i := range output {
+		output[i].Created = output[i].Created.In(loc)
+		if key, err := entity.ParseKey(output[i].Key); err == nil {
+			output[i].Timestamp = key.Date.In(loc).Format(time.RFC3339)
+		}
+	}
 }
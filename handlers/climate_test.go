@@ -37,7 +37,7 @@ func (c *ClimateHandlersSuite) SetupTest() {
 	repo := repository.NewClimateRepository(clientInstance)
 	usecase := usecase.NewClimateUsecase(repo)
 	climateHandler := handlers.NewClimateHandler(usecase)
-	router := router.InitializeRouter(climateHandler)
+	router := router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
 	c.router = router
 }
 
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/logging"
+	"bigtable_api/ratelimit"
+	"bigtable_api/usecase"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompareRequest holds the query parameters of /compare, which lines up the
+// forecasts and the observations of areas at the same dates.
+type CompareRequest struct {
+	AreaIDs []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	// Dates is a single complete date or day, or a range of complete dates.
+	Dates        []string `form:"date" binding:"max=2,dive,date"`
+	From         string   `form:"from" binding:"omitempty,date"`
+	To           string   `form:"to" binding:"omitempty,date"`
+	InclusiveEnd string   `form:"inclusive_end" binding:"omitempty,oneof=true false"`
+	// Vars are the compared variables, those of both payloads by default.
+	Vars  []string `form:"vars" binding:"max=50,dive,required"`
+	TZ    string   `form:"tz" binding:"omitempty,timezone"`
+	Count string   `form:"count" binding:"omitempty,oneof=true false"`
+}
+
+// validate checks the rules involving more than one parameter.
+func (r *CompareRequest) validate() []Violation {
+	var violations []Violation
+	if len(r.Dates) == 0 && r.From == "" && r.To == "" {
+		violations = append(violations, Violation{Field: "date", Rule: "required_without", Message: "date, or from and to, is required"})
+	}
+	if len(r.Dates) > 0 && (r.From != "" || r.To != "") {
+		violations = append(violations, Violation{Field: "date", Rule: "excluded_with", Message: "date can not be combined with from or to"})
+	}
+	for _, bound := range [][2]string{{"from", r.From}, {"to", r.To}} {
+		field, value := bound[0], bound[1]
+		if _, prefix, err := resolveDate(value, time.UTC); err == nil && prefix && len(value) != dayPrefixLength {
+			violations = append(violations, Violation{Field: field, Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: %s requires a day (YYYY-MM-DD) or a complete date", value, field)})
+		}
+	}
+	for _, date := range r.Dates {
+		if _, prefix, err := resolveDate(date, time.UTC); err == nil && prefix && (len(r.Dates) > 1 || len(date) != dayPrefixLength) {
+			violations = append(violations, Violation{Field: "date", Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: expected a day (YYYY-MM-DD) or a range of complete dates", date)})
+		}
+	}
+	return violations
+}
+
+// readRequest returns the equivalent read parameters, to resolve the dates.
+func (r *CompareRequest) readRequest() *ReadClimateRequest {
+	return &ReadClimateRequest{AreaIDs: r.AreaIDs, Dates: r.Dates, From: r.From, To: r.To, InclusiveEnd: r.InclusiveEnd, TZ: r.TZ}
+}
+
+// keyDates converts the dates to a single key date or a range, a day being
+// the range of keys it covers.
+func (r *CompareRequest) keyDates() ([]string, error) {
+	req := r.readRequest()
+	dates, err := req.keyDates()
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) == 1 && len(dates[0]) == dayPrefixLength {
+		return dayRange(dates[0], req.location())
+	}
+	return dates, nil
+}
+
+// Compare reads the forecasts and the observations of the areas, and returns
+// them paired by date with the difference of each variable.
+func (h *ClimateHandler) Compare(ctx *gin.Context) {
+	start := time.Now()
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
+
+	var req CompareRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	dates, err := req.keyDates()
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	principal := auth.PrincipalFrom(ctx)
+	for _, dataType := range []string{"w", "f"} {
+		if err := principal.Authorize(dataType, req.AreaIDs); err != nil {
+			logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+			return
+		}
+	}
+
+	comparisons, err := h.usecase.Compare(ctx, "climate_data", req.AreaIDs, dates, req.readRequest().filters(), req.Vars)
+	if err != nil {
+		logger.Error("error comparing", "areas", req.AreaIDs, "dates", dates, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	loc := req.readRequest().location()
+	for i := range comparisons {
+		comparison := &comparisons[i]
+		if date, err := entity.ParseKeyDate(comparison.Date); err == nil {
+			comparison.Timestamp = date.In(loc).Format(time.RFC3339)
+		}
+		comparison.ForecastCreated = comparison.ForecastCreated.In(loc)
+		comparison.ObservedCreated = comparison.ObservedCreated.In(loc)
+	}
+
+	result := gin.H{"result": comparisons, "status": "success"}
+	if req.Count == "true" {
+		result["count"] = len(comparisons)
+	}
+	ratelimit.RecordRows(ctx, len(comparisons))
+	logger.Info("Request successful", "rows", len(comparisons), "duration", time.Since(start))
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Defaults of the skill parameters.
+const (
+	defaultLeadStep      = 24
+	defaultRainThreshold = 0.2
+)
+
+// SkillRequest holds the query parameters of /compare/skill, those of
+// /compare and the grouping of the verification.
+type SkillRequest struct {
+	AreaIDs      []string `form:"area_id" binding:"required,max=50,dive,areaid"`
+	Dates        []string `form:"date" binding:"max=2,dive,date"`
+	From         string   `form:"from" binding:"omitempty,date"`
+	To           string   `form:"to" binding:"omitempty,date"`
+	InclusiveEnd string   `form:"inclusive_end" binding:"omitempty,oneof=true false"`
+	Vars         []string `form:"vars" binding:"max=50,dive,required"`
+	// LeadStep is the width, in hours, of the lead time groups.
+	LeadStep int `form:"lead_step" binding:"omitempty,min=1,max=240"`
+	// RainThreshold is the amount of rain, in mm, from which it rains.
+	RainThreshold *float64 `form:"rain_threshold" binding:"omitempty,min=0,max=100"`
+}
+
+func (r *SkillRequest) compareRequest() *CompareRequest {
+	return &CompareRequest{AreaIDs: r.AreaIDs, Dates: r.Dates, From: r.From, To: r.To, InclusiveEnd: r.InclusiveEnd, Vars: r.Vars}
+}
+
+func (r *SkillRequest) validate() []Violation {
+	return r.compareRequest().validate()
+}
+
+// Skill answers the verification statistics of the forecasts of the areas
+// against their observations, by area, variable and lead time.
+func (h *ClimateHandler) Skill(ctx *gin.Context) {
+	start := time.Now()
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
+
+	var req SkillRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	compare := req.compareRequest()
+	dates, err := compare.keyDates()
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	principal := auth.PrincipalFrom(ctx)
+	for _, dataType := range []string{"w", "f"} {
+		if err := principal.Authorize(dataType, req.AreaIDs); err != nil {
+			logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+			return
+		}
+	}
+
+	options := usecase.SkillOptions{
+		Variables:     req.Vars,
+		LeadTimeStep:  defaultLeadStep * time.Hour,
+		RainThreshold: defaultRainThreshold,
+		Versions:      MaxVersions,
+	}
+	if req.LeadStep != 0 {
+		options.LeadTimeStep = time.Duration(req.LeadStep) * time.Hour
+	}
+	if req.RainThreshold != nil {
+		options.RainThreshold = *req.RainThreshold
+	}
+	skills, err := h.usecase.Skill(ctx, "climate_data", req.AreaIDs, dates, compare.readRequest().filters(), options)
+	if err != nil {
+		logger.Error("error verifying forecasts", "areas", req.AreaIDs, "dates", dates, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	pairs := 0
+	for _, skill := range skills {
+		pairs += skill.Count
+	}
+	ratelimit.RecordRows(ctx, pairs)
+	logger.Info("Request successful", "groups", len(skills), "pairs", pairs, "duration", time.Since(start))
+	ctx.JSON(http.StatusOK, gin.H{"result": skills, "status": "success"})
+}
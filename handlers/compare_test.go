@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type CompareHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestCompareHandlersSuite(t *testing.T) {
+	suite.Run(t, new(CompareHandlersSuite))
+}
+
+func (c *CompareHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	lonlat := [2]float64{-50.59667, -17.749189}
+	observed := time.Date(2023, 10, 10, 3, 5, 0, 0, time.UTC)
+	issued := time.Date(2023, 10, 9, 12, 0, 0, 0, time.UTC)
+	// the latest forecast of 00:00 comes first, as Bigtable returns the versions
+	gateway.add("f/A327734/2023-10-10 00:00:00", issued, lonlat, map[string]float64{"temperatureInst": 27, "rain": 0})
+	gateway.add("f/A327734/2023-10-10 00:00:00", issued.Add(-24*time.Hour), lonlat, map[string]float64{"temperatureInst": 31, "rain": 3})
+	gateway.add("f/A327734/2023-10-10 01:00:00", issued, lonlat, map[string]float64{"temperatureInst": 26, "rain": 1})
+	gateway.add("f/A327734/2023-10-10 02:00:00", issued, lonlat, map[string]float64{"temperatureInst": 25})
+	gateway.add("w/A327734/2023-10-10 00:00:00", observed, lonlat, map[string]float64{"temperatureInst": 28.5, "rain": 0})
+	gateway.add("w/A327734/2023-10-10 01:00:00", observed, lonlat, map[string]float64{"temperatureInst": 25.5})
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(gateway))
+	c.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+type compareOutput struct {
+	Result []entity.Comparison `json:"result"`
+	Count  int                 `json:"count"`
+	Status string              `json:"status"`
+}
+
+func (c *CompareHandlersSuite) get(query url.Values) (int, compareOutput) {
+	req, err := http.NewRequest(http.MethodGet, "/compare?"+query.Encode(), nil)
+	c.Nil(err)
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+	var out compareOutput
+	c.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (c *CompareHandlersSuite) TestCompare() {
+	code, out := c.get(url.Values{"area_id": {"A327734"}, "date": {"2023-10-10"}, "vars": {"temperatureInst,rain"}, "count": {"true"}})
+	c.Equal(http.StatusOK, code)
+	// the forecast of 02:00 has no observation
+	c.Equal(2, out.Count)
+
+	first := out.Result[0]
+	c.Equal("A327734", first.AreaID)
+	c.Equal("2023-10-10 00:00:00", first.Date)
+	c.Equal("2023-10-10T00:00:00Z", first.Timestamp)
+	c.Equal(time.Date(2023, 10, 9, 12, 0, 0, 0, time.UTC), first.ForecastCreated)
+	c.Equal(27.0, *first.Variables["temperatureInst"].Forecast)
+	c.Equal(28.5, *first.Variables["temperatureInst"].Observed)
+	c.Equal(-1.5, *first.Variables["temperatureInst"].Difference)
+	c.Equal(0.0, *first.Variables["rain"].Difference)
+
+	// a variable missing from a payload is null
+	rain := out.Result[1].Variables["rain"]
+	c.Equal(1.0, *rain.Forecast)
+	c.Nil(rain.Observed)
+	c.Nil(rain.Difference)
+}
+
+func (c *CompareHandlersSuite) TestCommonVariables() {
+	code, out := c.get(url.Values{"area_id": {"A327734"}, "from": {"2023-10-10T01:00:00Z"}, "tz": {"America/Sao_Paulo"}})
+	c.Equal(http.StatusOK, code)
+	c.Len(out.Result, 1)
+	c.Equal("2023-10-09T22:00:00-03:00", out.Result[0].Timestamp)
+	c.Len(out.Result[0].Variables, 1)
+	c.Contains(out.Result[0].Variables, "temperatureInst")
+}
+
+func (c *CompareHandlersSuite) TestInvalidRequests() {
+	for _, query := range []url.Values{
+		{"date": {"2023-10-10"}},
+		{"area_id": {"A327734"}},
+		{"area_id": {"A327734"}, "date": {"2023-10"}},
+		{"area_id": {"A327734"}, "date": {"2023-10-10,2023-10-11 00:00:00"}},
+		{"area_id": {"A327734"}, "date": {"2023-10-10"}, "from": {"2023-10-10"}},
+	} {
+		code, _ := c.get(query)
+		c.Equal(http.StatusBadRequest, code, query.Encode())
+	}
+}
+
+type skillOutput struct {
+	Result []entity.Skill `json:"result"`
+	Status string         `json:"status"`
+}
+
+func (c *CompareHandlersSuite) TestSkill() {
+	req, err := http.NewRequest(http.MethodGet, "/compare/skill?"+url.Values{"area_id": {"A327734"}, "date": {"2023-10-10"}}.Encode(), nil)
+	c.Nil(err)
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+	c.Equal(http.StatusOK, w.Code)
+	var out skillOutput
+	c.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+
+	var groups []string
+	for _, skill := range out.Result {
+		groups = append(groups, fmt.Sprintf("%s %s %d-%d", skill.AreaID, skill.Variable, skill.LeadTimeFrom, skill.LeadTimeTo))
+	}
+	c.Equal([]string{
+		"A327734 rain 0-24",
+		"A327734 rain 24-48",
+		"A327734 temperatureInst 0-24",
+		"A327734 temperatureInst 24-48",
+	}, groups)
+
+	// the forecasts of 00:00 and 01:00 issued 12 and 13 hours before
+	temperature := out.Result[2]
+	c.Equal(2, temperature.Count)
+	c.InDelta(1.0, temperature.MAE, 1e-9)
+	c.InDelta(math.Sqrt(1.25), temperature.RMSE, 1e-9)
+	c.InDelta(-0.5, temperature.Bias, 1e-9)
+	c.Nil(temperature.RainOccurrence)
+
+	// the older forecast of 00:00, issued 36 hours before
+	c.InDelta(2.5, out.Result[3].Bias, 1e-9)
+
+	rain := out.Result[1].RainOccurrence
+	c.Equal(entity.Contingency{FalseAlarms: 1}, *rain)
+}
+
+func (c *CompareHandlersSuite) TestSkillHitRate() {
+	req, err := http.NewRequest(http.MethodGet, "/compare/skill?"+url.Values{"area_id": {"A327734"}, "date": {"2023-10-10"}, "vars": {"rain"}, "lead_step": {"48"}, "rain_threshold": {"0"}}.Encode(), nil)
+	c.Nil(err)
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+	c.Equal(http.StatusOK, w.Code)
+	var out skillOutput
+	c.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	c.Len(out.Result, 1)
+	// with a threshold of 0 every observation is an event
+	c.Equal(2, out.Result[0].RainOccurrence.Hits)
+	c.Equal(1.0, *out.Result[0].RainOccurrence.HitRate)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bigtable_api/entity"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayPrefixLength is the length of a YYYY-MM-DD date prefix.
+const dayPrefixLength = len("2006-01-02")
+
+// isoLayouts are the ISO-8601 layouts accepted besides the key layout. Those
+// without an offset are read in the timezone of the request.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// relativeDate matches dates relative to the time of the request, such as
+// now, now-24h or now+7d.
+var relativeDate = regexp.MustCompile(`^now(?:([+-])([0-9]+)([smhdw]))?$`)
+
+// resolveDate converts a date parameter to the date part of the row keys.
+// Complete dates, in the key layout or in ISO-8601, are read in loc unless they
+// carry an offset, and converted to the key timezone, as are relative dates.
+// Incomplete dates in the key layout are prefixes of keys, returned as they are.
+func resolveDate(value string, loc *time.Location) (date string, prefix bool, err error) {
+	if strings.HasPrefix(value, "now") {
+		t, err := resolveRelative(value, time.Now())
+		if err != nil {
+			return "", false, err
+		}
+		return entity.FormatKeyDate(t), false, nil
+	}
+	if strings.Contains(value, "T") {
+		for _, layout := range isoLayouts {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return entity.FormatKeyDate(t), false, nil
+			}
+		}
+		return "", false, errors.New("invalid ISO-8601 date " + value)
+	}
+	if len(value) == len(entity.KeyDateLayout) {
+		t, err := time.ParseInLocation(entity.KeyDateLayout, value, loc)
+		if err != nil {
+			return "", false, err
+		}
+		return entity.FormatKeyDate(t), false, nil
+	}
+	if isDatePrefix(value) {
+		return value, true, nil
+	}
+	return "", false, errors.New("invalid date " + value)
+}
+
+func resolveRelative(value string, now time.Time) (time.Time, error) {
+	match := relativeDate.FindStringSubmatch(value)
+	if match == nil {
+		return time.Time{}, errors.New("invalid relative date " + value)
+	}
+	if match[1] == "" {
+		return now, nil
+	}
+	amount, err := strconv.Atoi(match[2])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if match[1] == "-" {
+		amount = -amount
+	}
+	switch match[3] {
+	case "s":
+		return now.Add(time.Duration(amount) * time.Second), nil
+	case "m":
+		return now.Add(time.Duration(amount) * time.Minute), nil
+	case "h":
+		return now.Add(time.Duration(amount) * time.Hour), nil
+	case "d":
+		return now.AddDate(0, 0, amount), nil
+	default:
+		return now.AddDate(0, 0, 7*amount), nil
+	}
+}
+
+// maxLeadTime bounds the lead_time parameter.
+const maxLeadTime = 16 * 24 * time.Hour
+
+var leadTimePattern = regexp.MustCompile(`^([0-9]+)([hd])$`)
+
+// parseLeadTime converts a lead time in hours or days, such as 36h or 2d.
+func parseLeadTime(value string) (time.Duration, error) {
+	match := leadTimePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, errors.New("invalid lead time " + value)
+	}
+	amount, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, err
+	}
+	lead := time.Duration(amount) * time.Hour
+	if match[2] == "d" {
+		lead *= 24
+	}
+	if lead > maxLeadTime {
+		return 0, errors.New("lead time " + value + " is longer than 16 days")
+	}
+	return lead, nil
+}
+
+// resolveBound converts a from or to parameter to a key date. A day stands
+// for its first second in loc, and an empty value stays empty.
+func resolveBound(value string, loc *time.Location) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	date, prefix, err := resolveDate(value, loc)
+	if err != nil || !prefix {
+		return date, err
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return "", err
+	}
+	return entity.FormatKeyDate(day), nil
+}
+
+// isDatePrefix reports whether date is the key date layout, or a prefix of
+// it, filled with digits.
+func isDatePrefix(date string) bool {
+	layout := entity.KeyDateLayout
+	if date == "" || len(date) > len(layout) {
+		return false
+	}
+	for i := 0; i < len(date); i++ {
+		isDigit := date[i] >= '0' && date[i] <= '9'
+		if layoutIsDigit := layout[i] >= '0' && layout[i] <= '9'; layoutIsDigit != isDigit {
+			return false
+		}
+		if !isDigit && date[i] != layout[i] {
+			return false
+		}
+	}
+	if len(date) == len(layout) {
+		_, err := time.Parse(layout, date)
+		return err == nil
+	}
+	return true
+}
+
+// dayRange returns the range of key dates covering a local day, given as a
+// YYYY-MM-DD prefix. The end is exclusive.
+func dayRange(day string, loc *time.Location) ([]string, error) {
+	start, err := time.ParseInLocation("2006-01-02", day, loc)
+	if err != nil {
+		return nil, err
+	}
+	return []string{entity.FormatKeyDate(start), entity.FormatKeyDate(start.AddDate(0, 0, 1))}, nil
+}
+
+// resolveInterval converts an ISO-8601 instant or interval, as the datetime
+// parameter of OGC API-EDR, to key dates. An instant is a single date, or a
+// prefix of dates, and an interval a range with ".." for an open end.
+func resolveInterval(value string, loc *time.Location) ([]string, error) {
+	start, end, interval := strings.Cut(value, "/")
+	if !interval {
+		date, _, err := resolveDate(value, loc)
+		if err != nil {
+			return nil, err
+		}
+		return []string{date}, nil
+	}
+	if start == ".." {
+		start = ""
+	}
+	if end == ".." {
+		end = ""
+	}
+	if start == "" && end == "" {
+		return nil, errors.New("invalid interval " + value + ": both ends are open")
+	}
+	from, err := resolveBound(start, loc)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveBound(end, loc)
+	if err != nil {
+		return nil, err
+	}
+	return []string{from, to}, nil
+}
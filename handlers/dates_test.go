@@ -0,0 +1,158 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// recordingGateway keeps the arguments of the last read.
+type recordingGateway struct {
+	prefix  string
+	areas   []string
+	dates   []string
+	filters map[string]string
+}
+
+func (g *recordingGateway) ReadPrefix(ctx context.Context, table, prefix string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	g.prefix, g.areas, g.dates = prefix, nil, nil
+	return []entity.BigtableOutput{{Key: "w/A327734/2023-10-10 03:00:00"}}, nil
+}
+
+func (g *recordingGateway) ReadRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	g.prefix, g.areas, g.dates, g.filters = "", areas, dates, filters
+	return []entity.BigtableOutput{{Key: dataType + "/" + areas[0] + "/" + dates[0]}}, nil
+}
+
+func (g *recordingGateway) StreamPrefix(ctx context.Context, table, prefix string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadPrefix(ctx, table, prefix, filters)
+	return stream(output, fn)
+}
+
+func (g *recordingGateway) StreamRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadRows(ctx, table, dataType, areas, dates, filters)
+	return stream(output, fn)
+}
+
+func (g *recordingGateway) Write(ctx context.Context, table string, inputs []entity.BigtableInput) error {
+	return nil
+}
+
+type DatesHandlersSuite struct {
+	suite.Suite
+	gateway *recordingGateway
+}
+
+func TestDatesHandlersSuite(t *testing.T) {
+	suite.Run(t, new(DatesHandlersSuite))
+}
+
+func (d *DatesHandlersSuite) get(query url.Values) (int, output) {
+	d.gateway = &recordingGateway{}
+	r := router.InitializeRouter(handlers.NewClimateHandler(usecase.NewClimateUsecase(d.gateway)), nil, handlers.NewHealthHandler())
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?"+query.Encode(), nil)
+	d.Nil(err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out output
+	d.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (d *DatesHandlersSuite) TestISODates() {
+	code, _ := d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10T00:00:00-03:00"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("w/A327734/2023-10-10 03:00:00", d.gateway.prefix)
+
+	code, _ = d.get(url.Values{"type": {"w"}, "area_id": {"A327734,A327735"}, "date": {"2023-10-10T00:00:00Z,2023-10-11T12:30Z"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal([]string{"2023-10-10 00:00:00", "2023-10-11 12:30:00"}, d.gateway.dates)
+}
+
+func (d *DatesHandlersSuite) TestTimezone() {
+	// dates without offset are read in tz
+	code, out := d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10 00:00:00"}, "tz": {"America/Sao_Paulo"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("w/A327734/2023-10-10 03:00:00", d.gateway.prefix)
+	d.Equal("2023-10-10T00:00:00-03:00", out.Result[0].Timestamp)
+
+	// a local day becomes the range of keys it covers
+	code, _ = d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "tz": {"America/Manaus"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal([]string{"2023-10-10 04:00:00", "2023-10-11 04:00:00"}, d.gateway.dates)
+
+	// without tz, incomplete dates are still key prefixes
+	code, out = d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("w/A327734/2023-10-10", d.gateway.prefix)
+	d.Empty(out.Result[0].Timestamp)
+}
+
+func (d *DatesHandlersSuite) TestRelativeDates() {
+	code, _ := d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"now-24h,now"}})
+	d.Equal(http.StatusOK, code)
+	d.Len(d.gateway.dates, 2)
+	start, err := entity.ParseKeyDate(d.gateway.dates[0])
+	d.Nil(err)
+	end, err := entity.ParseKeyDate(d.gateway.dates[1])
+	d.Nil(err)
+	d.Equal(24*time.Hour, end.Sub(start))
+	d.WithinDuration(time.Now(), end, time.Minute)
+}
+
+func (d *DatesHandlersSuite) TestOpenEndedRanges() {
+	code, _ := d.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "from": {"2023-10-01"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal([]string{"2023-10-01 00:00:00", ""}, d.gateway.dates)
+
+	code, _ = d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "to": {"2023-10-01T12:00:00Z"}, "inclusive_end": {"true"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal([]string{"", "2023-10-01 12:00:00"}, d.gateway.dates)
+	d.Equal("true", d.gateway.filters["inclusive_end"])
+
+	code, _ = d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "from": {"now-7d"}, "tz": {"America/Sao_Paulo"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("", d.gateway.dates[1])
+}
+
+func (d *DatesHandlersSuite) TestIssuedAt() {
+	code, _ := d.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "issued_at": {"2023-10-09 12:00:00"}, "tz": {"America/Sao_Paulo"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("2023-10-09T15:00:00Z", d.gateway.filters["issued_at"])
+
+	code, _ = d.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"36h"}, "tz": {"America/Sao_Paulo"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal(strconv.Itoa(handlers.MaxVersions), d.gateway.filters["version"])
+}
+
+func (d *DatesHandlersSuite) TestFilterLocation() {
+	code, _ := d.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "filter": {"created < 2023-10-01"}, "tz": {"America/Sao_Paulo"}})
+	d.Equal(http.StatusOK, code)
+	d.Equal("America/Sao_Paulo", d.gateway.filters["tz"])
+}
+
+func (d *DatesHandlersSuite) TestInvalidDates() {
+	for _, query := range []url.Values{
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10T25:00:00Z"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "tz": {"Mars/Olympus"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10"}, "tz": {"America/Sao_Paulo"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"now-1y"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10 00:00:00"}, "from": {"2023-10-01"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "from": {"2023-10"}},
+		{"type": {"w"}, "from": {"2023-10-01"}},
+	} {
+		code, _ := d.get(query)
+		d.Equal(http.StatusBadRequest, code, query.Encode())
+	}
+}
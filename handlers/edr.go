@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/export"
+	"bigtable_api/logging"
+	"bigtable_api/ratelimit"
+	"bigtable_api/stations"
+	"bigtable_api/usecase"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PositionMaxDistance is how far, in kilometers, the nearest station of a
+// position query can be.
+const PositionMaxDistance = 100.0
+
+// defaultInterval is read when a query has no datetime.
+const defaultInterval = "now-24h/.."
+
+// collection is an OGC API-EDR collection, one per datatype.
+type collection struct {
+	dataType    string
+	title       string
+	description string
+}
+
+var collections = map[string]collection{
+	"weather":  {dataType: "w", title: "Weather observations", description: "Hourly observations of the weather stations"},
+	"forecast": {dataType: "f", title: "Weather forecast", description: "Hourly forecast at the weather stations"},
+}
+
+var collectionIDs = []string{"weather", "forecast"}
+
+var wktPoint = regexp.MustCompile(`(?i)^POINT\s*\(\s*(-?[0-9.]+)\s+(-?[0-9.]+)\s*\)$`)
+
+// EDRHandler exposes the climate data through OGC API-EDR, answering data
+// queries with CoverageJSON. The positions of the areas come from the station
+// catalog.
+type EDRHandler struct {
+	usecase  *usecase.ClimateUsecase
+	stations *stations.Catalog
+}
+
+func NewEDRHandler(climateUsecase *usecase.ClimateUsecase, catalog *stations.Catalog) *EDRHandler {
+	return &EDRHandler{usecase: climateUsecase, stations: catalog}
+}
+
+// LocationRequest holds the query parameters of the EDR locations query.
+type LocationRequest struct {
+	// Datetime is an instant or an interval, the last day by default.
+	Datetime       string   `form:"datetime" binding:"omitempty,interval"`
+	ParameterNames []string `form:"parameter-name" binding:"max=50"`
+	F              string   `form:"f" binding:"omitempty,oneof=CoverageJSON"`
+}
+
+func (r *LocationRequest) validate() []Violation {
+	return nil
+}
+
+// PositionRequest holds the query parameters of the EDR position query.
+type PositionRequest struct {
+	Coords         string   `form:"coords" binding:"required,wkt_point"`
+	Datetime       string   `form:"datetime" binding:"omitempty,interval"`
+	ParameterNames []string `form:"parameter-name" binding:"max=50"`
+	F              string   `form:"f" binding:"omitempty,oneof=CoverageJSON"`
+}
+
+func (r *PositionRequest) validate() []Violation {
+	return nil
+}
+
+// point returns the longitude and latitude of the validated coords.
+func (r *PositionRequest) point() (lon, lat float64) {
+	match := wktPoint.FindStringSubmatch(r.Coords)
+	lon, _ = strconv.ParseFloat(match[1], 64)
+	lat, _ = strconv.ParseFloat(match[2], 64)
+	return lon, lat
+}
+
+// validPoint reports whether coords is a WKT point in CRS84.
+func validPoint(coords string) bool {
+	match := wktPoint.FindStringSubmatch(coords)
+	if match == nil {
+		return false
+	}
+	lon, errLon := strconv.ParseFloat(match[1], 64)
+	lat, errLat := strconv.ParseFloat(match[2], 64)
+	return errLon == nil && errLat == nil && math.Abs(lon) <= 180 && math.Abs(lat) <= 90
+}
+
+// Collections lists the collections.
+func (h *EDRHandler) Collections(ctx *gin.Context) {
+	base := baseURL(ctx)
+	list := make([]gin.H, 0, len(collectionIDs))
+	for _, id := range collectionIDs {
+		list = append(list, h.collection(base, id))
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"links":       []gin.H{link(base+"/collections", "self", gin.MIMEJSON)},
+		"collections": list,
+	})
+}
+
+// Collection describes a collection and its data queries.
+func (h *EDRHandler) Collection(ctx *gin.Context) {
+	id := ctx.Param("collectionId")
+	if _, ok := collections[id]; !ok {
+		abortNotFound(ctx, "collection "+id+" not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, h.collection(baseURL(ctx), id))
+}
+
+// Locations lists the stations of the catalog as GeoJSON.
+func (h *EDRHandler) Locations(ctx *gin.Context) {
+	id := ctx.Param("collectionId")
+	col, ok := collections[id]
+	if !ok {
+		abortNotFound(ctx, "collection "+id+" not found")
+		return
+	}
+	principal := auth.PrincipalFrom(ctx)
+	if principal != nil && !principal.AllowsDatatype(col.dataType) {
+		logging.FromContext(ctx).Warn("error authorizing request", "principal", principal.ID, "datatype", col.dataType)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": "datatype " + col.dataType + " is not allowed"})
+		return
+	}
+	features := []gin.H{}
+	for _, station := range h.stations.All() {
+		if principal != nil && !principal.AllowsArea(station.AreaID) {
+			continue
+		}
+		properties := gin.H{"name": station.Name}
+		if station.Elevation != 0 {
+			properties["elevation"] = station.Elevation
+		}
+		features = append(features, gin.H{
+			"type":       "Feature",
+			"id":         station.AreaID,
+			"geometry":   gin.H{"type": "Point", "coordinates": []float64{station.Lon, station.Lat}},
+			"properties": properties,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+}
+
+// Location returns the time series of an area.
+func (h *EDRHandler) Location(ctx *gin.Context) {
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
+	id := ctx.Param("collectionId")
+	col, ok := collections[id]
+	if !ok {
+		abortNotFound(ctx, "collection "+id+" not found")
+		return
+	}
+	areaID := ctx.Param("locationId")
+	if !areaIDPattern.MatchString(areaID) {
+		abortValidation(ctx, logger, &ValidationError{Violations: []Violation{{Field: "locationId", Rule: "areaid", Message: fmt.Sprintf("invalid area ID %q: expected 'A' followed by digits", areaID)}}})
+		return
+	}
+	var req LocationRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	station, located := h.stations.Get(areaID)
+	h.writeCoverage(ctx, logger, col, station, located, areaID, req.Datetime, req.ParameterNames)
+}
+
+// Position returns the time series of the station nearest to a point, among
+// those of the areas the principal can read.
+func (h *EDRHandler) Position(ctx *gin.Context) {
+	logger := logging.FromContext(ctx).With("query", ctx.Request.URL.RawQuery)
+	id := ctx.Param("collectionId")
+	col, ok := collections[id]
+	if !ok {
+		abortNotFound(ctx, "collection "+id+" not found")
+		return
+	}
+	var req PositionRequest
+	if err := bindQuery(ctx, &req); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	// the nearest of the stations the principal can read, so that the others
+	// are not disclosed
+	principal := auth.PrincipalFrom(ctx)
+	if principal != nil && !principal.AllowsDatatype(col.dataType) {
+		logger.Warn("error authorizing request", "principal", principal.ID, "datatype", col.dataType)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": "datatype " + col.dataType + " is not allowed"})
+		return
+	}
+	lon, lat := req.point()
+	station, distance, ok := h.stations.Nearest(lon, lat, func(station stations.Station) bool {
+		return principal == nil || principal.AllowsArea(station.AreaID)
+	})
+	if !ok || distance > PositionMaxDistance {
+		abortNotFound(ctx, fmt.Sprintf("no station within %.0f km of %s", PositionMaxDistance, req.Coords))
+		return
+	}
+	h.writeCoverage(ctx, logger.With("station", station.AreaID, "distance_km", distance), col, station, true, station.AreaID, req.Datetime, req.ParameterNames)
+}
+
+// writeCoverage reads an area and answers its CoverageJSON. Without a station
+// in the catalog, the position of the area is taken from its payloads.
+func (h *EDRHandler) writeCoverage(ctx *gin.Context, logger *slog.Logger, col collection, station stations.Station, located bool, areaID, datetime string, parameters []string) {
+	start := time.Now()
+	principal := auth.PrincipalFrom(ctx)
+	if err := principal.Authorize(col.dataType, []string{areaID}); err != nil {
+		logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+
+	if datetime == "" {
+		datetime = defaultInterval
+	}
+	dates, err := resolveInterval(datetime, time.UTC)
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+
+	var output []entity.BigtableOutput
+	if len(dates) == 1 {
+		output, err = h.usecase.ReadPrefix(ctx, "climate_data", map[string]string{}, col.dataType, areaID, dates[0])
+	} else {
+		// EDR intervals include their end
+		output, err = h.usecase.Read(ctx, "climate_data", col.dataType, map[string]string{"inclusive_end": "true"}, []string{areaID}, dates)
+	}
+	if err != nil {
+		logger.Error("error reading location", "area", areaID, "dates", dates, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	ratelimit.RecordRows(ctx, len(output))
+
+	if !located {
+		for _, cell := range output {
+			payload, err := entity.DecodePayload(cell.Value)
+			if lon, ok := payload.Lon(); err == nil && ok {
+				station.Lon = lon
+				station.Lat, _ = payload.Lat()
+				located = true
+				break
+			}
+		}
+	}
+	if !located {
+		abortNotFound(ctx, "no data for location "+areaID)
+		return
+	}
+
+	logger.Info("Request successful", "rows", len(output), "duration", time.Since(start))
+	ctx.Header("Content-Type", export.CoverageJSONType)
+	ctx.JSON(http.StatusOK, export.PointSeries(station.Lon, station.Lat, output, parameters))
+}
+
+// collection describes a collection, with the extent of the catalog stations.
+func (h *EDRHandler) collection(base, id string) gin.H {
+	col := collections[id]
+	href := base + "/collections/" + id
+	parameters := make(map[string]export.Parameter, len(entity.WeatherVariables))
+	for _, name := range entity.WeatherVariables {
+		parameters[name] = export.ParameterOf(name)
+	}
+	extent := gin.H{}
+	if all := h.stations.All(); len(all) > 0 {
+		bbox := []float64{all[0].Lon, all[0].Lat, all[0].Lon, all[0].Lat}
+		for _, station := range all {
+			bbox = []float64{math.Min(bbox[0], station.Lon), math.Min(bbox[1], station.Lat), math.Max(bbox[2], station.Lon), math.Max(bbox[3], station.Lat)}
+		}
+		extent["spatial"] = gin.H{"bbox": [][]float64{bbox}, "crs": "OGC:CRS84"}
+	}
+	query := func(queryType, path string) gin.H {
+		l := link(href+path, "data", export.CoverageJSONType)
+		l["variables"] = gin.H{
+			"query_type":            queryType,
+			"output_formats":        []string{"CoverageJSON"},
+			"default_output_format": "CoverageJSON",
+		}
+		return gin.H{"link": l}
+	}
+	return gin.H{
+		"id":          id,
+		"title":       col.title,
+		"description": col.description,
+		"links":       []gin.H{link(href, "self", gin.MIMEJSON)},
+		"extent":      extent,
+		"data_queries": gin.H{
+			"position":  query("position", "/position"),
+			"locations": query("locations", "/locations"),
+		},
+		"crs":             []string{"OGC:CRS84"},
+		"output_formats":  []string{"CoverageJSON"},
+		"parameter_names": parameters,
+	}
+}
+
+func link(href, rel, mediaType string) gin.H {
+	return gin.H{"href": href, "rel": rel, "type": mediaType}
+}
+
+// baseURL is the URL the client reached the API with.
+func baseURL(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := ctx.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + ctx.Request.Host
+}
+
+func abortNotFound(ctx *gin.Context, message string) {
+	ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"status": "failed", "error": message})
+}
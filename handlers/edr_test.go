@@ -0,0 +1,209 @@
+package handlers_test
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/stations"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type EDRHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+	// scoped authenticates the requests with the API keys of scopedKeys
+	scoped *gin.Engine
+}
+
+// scopedKeys can read the forecasts, Rio Verde and an area without a station.
+const scopedKeys = `{"keys": [
+	{"id": "forecast-only", "hash": "%s", "scopes": {"datatypes": ["f"]}},
+	{"id": "rio-verde", "hash": "%s", "scopes": {"areas": ["A327735"]}},
+	{"id": "unlocated", "hash": "%s", "scopes": {"areas": ["A42"]}}
+]}`
+
+func TestEDRHandlersSuite(t *testing.T) {
+	suite.Run(t, new(EDRHandlersSuite))
+}
+
+func (e *EDRHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	gateway.add("w/A327734/2023-10-10 00:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 28.79, "rain": 0})
+	gateway.add("w/A327734/2023-10-10 01:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 27.5})
+	gateway.add("w/A327734/2023-10-10 02:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 26.1, "rain": 0.4})
+	gateway.add("w/A42/2023-10-10 00:00:00", created, [2]float64{-47.1, -19.2}, map[string]float64{"temperatureInst": 30})
+	catalog, err := stations.NewCatalog([]stations.Station{
+		{AreaID: "A327734", Name: "Jatai", Lon: -50.59667, Lat: -17.749189, Elevation: 680},
+		{AreaID: "A327735", Name: "Rio Verde", Lon: -50.9, Lat: -17.8},
+	})
+	e.Require().Nil(err)
+	climateUsecase := usecase.NewClimateUsecase(gateway)
+	e.router = router.InitializeRouter(handlers.NewClimateHandler(climateUsecase), handlers.NewEDRHandler(climateUsecase, catalog), handlers.NewHealthHandler())
+
+	keysFile := filepath.Join(e.T().TempDir(), "keys.json")
+	keys := fmt.Sprintf(scopedKeys, auth.HashKey("forecast-key"), auth.HashKey("rio-verde-key"), auth.HashKey("unlocated-key"))
+	e.Require().Nil(os.WriteFile(keysFile, []byte(keys), 0600))
+	keyStore, err := auth.NewKeyStore(keysFile)
+	e.Require().Nil(err)
+	e.scoped = router.InitializeRouter(handlers.NewClimateHandler(climateUsecase), handlers.NewEDRHandler(climateUsecase, catalog), handlers.NewHealthHandler(), auth.Middleware(keyStore, nil))
+}
+
+func (e *EDRHandlersSuite) get(target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	e.Nil(err)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	var body map[string]interface{}
+	e.Nil(json.Unmarshal(w.Body.Bytes(), &body))
+	return w, body
+}
+
+func (e *EDRHandlersSuite) TestCollections() {
+	w, body := e.get("/collections")
+	e.Equal(http.StatusOK, w.Code)
+	collections := body["collections"].([]interface{})
+	e.Len(collections, 2)
+	weather := collections[0].(map[string]interface{})
+	e.Equal("weather", weather["id"])
+	e.Equal([]interface{}{[]interface{}{-50.9, -17.8, -50.59667, -17.749189}}, weather["extent"].(map[string]interface{})["spatial"].(map[string]interface{})["bbox"])
+	position := weather["data_queries"].(map[string]interface{})["position"].(map[string]interface{})["link"].(map[string]interface{})
+	e.Equal("http://api.example.com/collections/weather/position", position["href"])
+	temperature := weather["parameter_names"].(map[string]interface{})["temperatureInst"].(map[string]interface{})
+	e.Equal("degC", temperature["unit"].(map[string]interface{})["symbol"])
+
+	w, _ = e.get("/collections/forecast")
+	e.Equal(http.StatusOK, w.Code)
+	w, _ = e.get("/collections/unknown")
+	e.Equal(http.StatusNotFound, w.Code)
+}
+
+func (e *EDRHandlersSuite) TestLocations() {
+	w, body := e.get("/collections/weather/locations")
+	e.Equal(http.StatusOK, w.Code)
+	e.Equal("FeatureCollection", body["type"])
+	features := body["features"].([]interface{})
+	e.Len(features, 2)
+	jatai := features[0].(map[string]interface{})
+	e.Equal("A327734", jatai["id"])
+	e.Equal([]interface{}{-50.59667, -17.749189}, jatai["geometry"].(map[string]interface{})["coordinates"])
+	e.Equal(680.0, jatai["properties"].(map[string]interface{})["elevation"])
+}
+
+func (e *EDRHandlersSuite) TestLocation() {
+	w, body := e.get("/collections/weather/locations/A327734?datetime=2023-10-10T00:00:00Z/2023-10-10T01:00:00Z")
+	e.Equal(http.StatusOK, w.Code)
+	e.Equal("application/prs.coverage+json", w.Header().Get("Content-Type"))
+	e.Equal("Coverage", body["type"])
+	axes := body["domain"].(map[string]interface{})["axes"].(map[string]interface{})
+	e.Equal([]interface{}{-50.59667}, axes["x"].(map[string]interface{})["values"])
+	e.Equal([]interface{}{"2023-10-10T00:00:00Z", "2023-10-10T01:00:00Z"}, axes["t"].(map[string]interface{})["values"])
+	ranges := body["ranges"].(map[string]interface{})
+	e.Equal([]interface{}{28.79, 27.5}, ranges["temperatureInst"].(map[string]interface{})["values"])
+	e.Equal([]interface{}{0.0, nil}, ranges["rain"].(map[string]interface{})["values"])
+	parameter := body["parameters"].(map[string]interface{})["rain"].(map[string]interface{})
+	e.Equal("http://vocab.nerc.ac.uk/standard_name/lwe_thickness_of_precipitation_amount/", parameter["observedProperty"].(map[string]interface{})["id"])
+}
+
+func (e *EDRHandlersSuite) TestLocationParameters() {
+	w, body := e.get("/collections/weather/locations/A327734?datetime=2023-10-10T01:00:00Z/..&parameter-name=rain")
+	e.Equal(http.StatusOK, w.Code)
+	ranges := body["ranges"].(map[string]interface{})
+	e.Len(ranges, 1)
+	e.Equal([]interface{}{nil, 0.4}, ranges["rain"].(map[string]interface{})["values"])
+}
+
+func (e *EDRHandlersSuite) TestLocationOutsideCatalog() {
+	// the position comes from the payloads
+	w, body := e.get("/collections/weather/locations/A42?datetime=2023-10-10")
+	e.Equal(http.StatusOK, w.Code)
+	axes := body["domain"].(map[string]interface{})["axes"].(map[string]interface{})
+	e.Equal([]interface{}{-47.1}, axes["x"].(map[string]interface{})["values"])
+
+	w, _ = e.get("/collections/weather/locations/A43?datetime=2023-10-10")
+	e.Equal(http.StatusNotFound, w.Code)
+	w, _ = e.get("/collections/weather/locations/43")
+	e.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (e *EDRHandlersSuite) TestPosition() {
+	w, body := e.get("/collections/weather/position?coords=POINT(-50.6%20-17.75)&datetime=2023-10-10T02:00:00Z")
+	e.Equal(http.StatusOK, w.Code)
+	ranges := body["ranges"].(map[string]interface{})
+	e.Equal([]interface{}{26.1}, ranges["temperatureInst"].(map[string]interface{})["values"])
+
+	w, _ = e.get("/collections/weather/position?coords=POINT(-40%20-10)")
+	e.Equal(http.StatusNotFound, w.Code)
+}
+
+// getScoped answers the path for the API key, with the body as a string.
+func (e *EDRHandlersSuite) getScoped(path, key string) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	e.Nil(err)
+	req.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	e.scoped.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func (e *EDRHandlersSuite) TestLocationsScopes() {
+	code, body := e.getScoped("/collections/weather/locations", "rio-verde-key")
+	e.Equal(http.StatusOK, code)
+	e.Contains(body, "A327735")
+	e.NotContains(body, "A327734")
+
+	// the stations are not listed without the datatype of the collection
+	code, body = e.getScoped("/collections/weather/locations", "forecast-key")
+	e.Equal(http.StatusForbidden, code)
+	e.NotContains(body, "A327734")
+	code, body = e.getScoped("/collections/forecast/locations", "forecast-key")
+	e.Equal(http.StatusOK, code)
+	e.Contains(body, "A327734")
+}
+
+func (e *EDRHandlersSuite) TestPositionScopes() {
+	get := func(key string) (int, string) {
+		return e.getScoped("/collections/weather/position?coords=POINT(-50.6%20-17.75)&datetime=2023-10-10T02:00:00Z", key)
+	}
+
+	// Jatai is nearer, but only Rio Verde can be read
+	code, body := get("rio-verde-key")
+	e.Equal(http.StatusOK, code)
+	e.Contains(body, "-50.9")
+	e.NotContains(body, "A327734")
+
+	// no station that can be read is near, and the nearest one is not disclosed
+	code, body = get("unlocated-key")
+	e.Equal(http.StatusNotFound, code)
+	e.NotContains(body, "A327734")
+
+	code, body = get("forecast-key")
+	e.Equal(http.StatusForbidden, code)
+	e.NotContains(body, "A327734")
+}
+
+func (e *EDRHandlersSuite) TestInvalidQueries() {
+	w, body := e.get("/collections/weather/position?coords=POINT(-200%200)&datetime=../..")
+	e.Equal(http.StatusBadRequest, w.Code)
+	var rules []string
+	for _, violation := range body["violations"].([]interface{}) {
+		v := violation.(map[string]interface{})
+		rules = append(rules, v["field"].(string)+":"+v["rule"].(string))
+	}
+	e.ElementsMatch([]string{"coords:wkt_point", "datetime:interval"}, rules)
+
+	w, _ = e.get("/collections/weather/position")
+	e.Equal(http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,149 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportHandlersSuite struct {
+	suite.Suite
+	gateway *fixtureGateway
+	router  *gin.Engine
+}
+
+func TestExportHandlersSuite(t *testing.T) {
+	suite.Run(t, new(ExportHandlersSuite))
+}
+
+func (e *ExportHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	gateway.add("w/A327734/2023-10-10 00:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 28.79, "rain": 0})
+	gateway.add("w/A327734/2023-10-10 01:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 27.5, "rain": 1.2})
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(gateway))
+	e.gateway = gateway
+	e.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+func (e *ExportHandlersSuite) get(query url.Values, accept string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?"+query.Encode(), nil)
+	e.Nil(err)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// csvRow joins the key columns and a column per weather variable, empty when
+// it has no value.
+func csvRow(delimiter string, keys []string, values map[string]string) string {
+	row := append([]string{}, keys...)
+	for _, name := range entity.WeatherVariables {
+		row = append(row, values[name])
+	}
+	return strings.Join(row, delimiter)
+}
+
+// csvHeader is the header of the CSV exports of the weather variables.
+func csvHeader(delimiter string) string {
+	names := make(map[string]string, len(entity.WeatherVariables))
+	for _, name := range entity.WeatherVariables {
+		names[name] = name
+	}
+	return csvRow(delimiter, []string{"datatype", "area_id", "timestamp", "created", "lon", "lat"}, names)
+}
+
+func (e *ExportHandlersSuite) TestCSV() {
+	w := e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "format": {"csv"}}, "")
+	e.Equal(http.StatusOK, w.Code)
+	e.Equal("text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	e.Equal(strings.Join([]string{
+		csvHeader(","),
+		csvRow(",", []string{"w", "A327734", "2023-10-10T00:00:00Z", "2023-10-11T03:00:00Z", "-50.59667", "-17.749189"}, map[string]string{"temperatureInst": "28.79", "rain": "0"}),
+		csvRow(",", []string{"w", "A327734", "2023-10-10T01:00:00Z", "2023-10-11T03:00:00Z", "-50.59667", "-17.749189"}, map[string]string{"temperatureInst": "27.5", "rain": "1.2"}),
+	}, "\n")+"\n", w.Body.String())
+
+	// the derived variables follow the weather ones, even when no cell has them
+	w = e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "format": {"csv"}, "derived": {"windChill,dewPoint"}}, "")
+	e.Equal(http.StatusOK, w.Code)
+	header, _, _ := strings.Cut(w.Body.String(), "\n")
+	e.Equal(csvHeader(",")+",windChill,dewPoint", header)
+}
+
+func (e *ExportHandlersSuite) TestCSVForSpreadsheets() {
+	w := e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-09 22:00:00"}, "delimiter": {";"}, "decimal": {","}, "tz": {"America/Sao_Paulo"}}, "text/csv")
+	e.Equal(http.StatusOK, w.Code)
+	e.Equal(strings.Join([]string{
+		csvHeader(";"),
+		csvRow(";", []string{"w", "A327734", "2023-10-09T22:00:00-03:00", "2023-10-11T00:00:00-03:00", "-50,59667", "-17,749189"}, map[string]string{"temperatureInst": "27,5", "rain": "1,2"}),
+	}, "\n")+"\n", w.Body.String())
+}
+
+func (e *ExportHandlersSuite) TestEmptyCSV() {
+	w := e.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "format": {"csv"}}, "")
+	e.Equal(http.StatusOK, w.Code)
+	e.Equal(csvHeader(",")+"\n", w.Body.String())
+}
+
+func (e *ExportHandlersSuite) TestInvalidSeparators() {
+	w := e.get(url.Values{"type": {"w"}, "format": {"csv"}, "decimal": {","}}, "")
+	e.Equal(http.StatusBadRequest, w.Code)
+	w = e.get(url.Values{"type": {"w"}, "format": {"csv"}, "delimiter": {"."}}, "")
+	e.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (e *ExportHandlersSuite) TestParquet() {
+	for _, w := range []*httptest.ResponseRecorder{
+		e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "format": {"parquet"}}, ""),
+		e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}}, "application/vnd.apache.parquet"),
+	} {
+		e.Equal(http.StatusOK, w.Code)
+		e.Equal("application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+		e.Equal(`attachment; filename="climate-data.parquet"`, w.Header().Get("Content-Disposition"))
+		e.True(strings.HasPrefix(w.Body.String(), "PAR1"))
+		e.True(strings.HasSuffix(w.Body.String(), "PAR1"))
+	}
+}
+
+func (e *ExportHandlersSuite) TestNetCDF() {
+	for _, w := range []*httptest.ResponseRecorder{
+		e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}, "format": {"netcdf"}}, ""),
+		e.get(url.Values{"type": {"w"}, "area_id": {"A327734"}}, "application/x-netcdf"),
+	} {
+		e.Equal(http.StatusOK, w.Code)
+		e.Equal("application/x-netcdf", w.Header().Get("Content-Type"))
+		e.Equal(`attachment; filename="climate-data.nc"`, w.Header().Get("Content-Disposition"))
+		e.True(strings.HasPrefix(w.Body.String(), "CDF\x02"))
+		e.Contains(w.Body.String(), "air_temperature")
+	}
+}
+
+func (e *ExportHandlersSuite) TestNetCDFTooLarge() {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < handlers.MaxNetCDFCells; i++ {
+		key := "w/A327735/" + start.Add(time.Duration(i)*time.Hour).Format("2006-01-02 15:04:05")
+		e.gateway.cells = append(e.gateway.cells, entity.BigtableOutput{Key: key, Value: `{"weatherData":{"rain":0}}`})
+	}
+	w := e.get(url.Values{"type": {"w"}, "area_id": {"A327735"}, "format": {"netcdf"}}, "")
+	e.Equal(http.StatusOK, w.Code)
+
+	e.gateway.cells = append(e.gateway.cells, entity.BigtableOutput{Key: "w/A327735/2030-01-01 00:00:00", Value: `{"weatherData":{"rain":0}}`})
+	w = e.get(url.Values{"type": {"w"}, "area_id": {"A327735"}, "format": {"netcdf"}}, "")
+	e.Equal(http.StatusBadRequest, w.Code)
+	e.Equal("application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	e.Empty(w.Header().Get("Content-Disposition"))
+	e.Contains(w.Body.String(), "netcdf exports hold at most 200000 cells")
+}
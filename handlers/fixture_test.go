@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"bigtable_api/entity"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fixtureGateway serves a fixed set of cells, selecting them by key like the
+// Bigtable repository does, so that handlers can be tested on real payloads.
+type fixtureGateway struct {
+	cells []entity.BigtableOutput
+}
+
+func (g *fixtureGateway) ReadPrefix(ctx context.Context, table, prefix string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	var output []entity.BigtableOutput
+	for _, cell := range g.cells {
+		if strings.HasPrefix(cell.Key, prefix) {
+			output = append(output, cell)
+		}
+	}
+	return output, nil
+}
+
+func (g *fixtureGateway) ReadRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error) {
+	var output []entity.BigtableOutput
+	for _, area := range areas {
+		prefix := dataType + "/" + area + "/"
+		for _, cell := range g.cells {
+			if !strings.HasPrefix(cell.Key, prefix) {
+				continue
+			}
+			date := strings.TrimPrefix(cell.Key, prefix)
+			if len(dates) == 1 && date == dates[0] ||
+				len(dates) == 2 && date >= dates[0] && (dates[1] == "" || date < dates[1] || filters["inclusive_end"] == "true" && date == dates[1]) {
+				output = append(output, cell)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (g *fixtureGateway) StreamPrefix(ctx context.Context, table, prefix string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadPrefix(ctx, table, prefix, filters)
+	return stream(output, fn)
+}
+
+func (g *fixtureGateway) StreamRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	output, _ := g.ReadRows(ctx, table, dataType, areas, dates, filters)
+	return stream(output, fn)
+}
+
+func (g *fixtureGateway) Write(ctx context.Context, table string, inputs []entity.BigtableInput) error {
+	// the new cells are the latest versions of their keys
+	cells := make([]entity.BigtableOutput, 0, len(inputs)+len(g.cells))
+	for _, input := range inputs {
+		cells = append(cells, entity.BigtableOutput{Key: input.Key, Created: input.Created, Value: input.Value})
+	}
+	g.cells = append(cells, g.cells...)
+	sort.SliceStable(g.cells, func(i, j int) bool { return g.cells[i].Key < g.cells[j].Key })
+	return nil
+}
+
+// add stores a cell for the key, created at created, with the variables as
+// its weatherData.
+func (g *fixtureGateway) add(key string, created time.Time, lonlat [2]float64, variables map[string]float64) {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]string, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, fmt.Sprintf("%q:%v", name, variables[name]))
+	}
+	value := fmt.Sprintf(`{"lonlat":[%v,%v],"weatherData":{%s}}`, lonlat[0], lonlat[1], strings.Join(fields, ","))
+	g.cells = append(g.cells, entity.BigtableOutput{Key: key, Created: created, Value: value})
+	sort.SliceStable(g.cells, func(i, j int) bool { return g.cells[i].Key < g.cells[j].Key })
+}
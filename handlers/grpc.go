@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/climatepb"
+	"bigtable_api/entity"
+	"bigtable_api/logging"
+	"bigtable_api/usecase"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// writeBatchSize is how many cells Write receives before storing them.
+const writeBatchSize = 1000
+
+// ClimateGRPCServer serves the climate data over gRPC, with the same
+// validation and authorization as the REST handlers.
+type ClimateGRPCServer struct {
+	climatepb.UnimplementedClimateServiceServer
+	usecase *usecase.ClimateUsecase
+}
+
+func NewClimateGRPCServer(climateUsecase *usecase.ClimateUsecase) *ClimateGRPCServer {
+	return &ClimateGRPCServer{usecase: climateUsecase}
+}
+
+func (s *ClimateGRPCServer) ReadClimateData(in *climatepb.ReadClimateDataRequest, stream climatepb.ClimateService_ReadClimateDataServer) error {
+	start := time.Now()
+	ctx := stream.Context()
+	logger := logging.FromContext(ctx).With("method", "ReadClimateData")
+
+	req := ReadClimateRequest{
+		Type:    in.Type,
+		AreaIDs: in.AreaIds,
+		Dates:   in.Dates,
+		From:    in.From,
+		To:      in.To,
+		Regexp:  in.Regexp,
+		TZ:      in.Tz,
+	}
+	if in.InclusiveEnd {
+		req.InclusiveEnd = "true"
+	}
+	if in.Version != 0 {
+		req.Version = strconv.Itoa(int(in.Version))
+	}
+	if err := validateRequest(&req); err != nil {
+		logger.Warn("invalid request", "error", err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	dates, err := req.keyDates()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	principal := auth.PrincipalFromContext(ctx)
+	if err := authorize(principal, auth.OperationRead, req.Type, req.AreaIDs); err != nil {
+		logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+		return err
+	}
+
+	rows := 0
+	var sendErr error
+	send := func(output entity.BigtableOutput) bool {
+		if sendErr = stream.Send(cell(output)); sendErr != nil {
+			return false
+		}
+		rows++
+		return true
+	}
+	if len(req.AreaIDs) > 1 || len(dates) > 1 {
+		err = s.usecase.Stream(ctx, "climate_data", req.Type, req.filters(), req.AreaIDs, dates, send)
+	} else {
+		err = s.usecase.StreamPrefix(ctx, "climate_data", req.filters(), send, req.prefixes(dates, principal)...)
+	}
+	if sendErr != nil {
+		logger.Warn("error sending cells", "rows", rows, "error", sendErr)
+		return sendErr
+	}
+	if err != nil {
+		logger.Error("error reading", "rows", rows, "error", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	logger.Info("Request successful", "rows", rows, "duration", time.Since(start))
+	return nil
+}
+
+func (s *ClimateGRPCServer) Latest(ctx context.Context, in *climatepb.LatestRequest) (*climatepb.Cell, error) {
+	logger := logging.FromContext(ctx).With("method", "Latest")
+	if in.Type != "w" && in.Type != "f" {
+		return nil, status.Error(codes.InvalidArgument, "type must be one of: w f")
+	}
+	if !areaIDPattern.MatchString(in.AreaId) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid area ID %q: expected 'A' followed by digits", in.AreaId)
+	}
+	principal := auth.PrincipalFromContext(ctx)
+	if err := authorize(principal, auth.OperationRead, in.Type, []string{in.AreaId}); err != nil {
+		logger.Warn("error authorizing request", "principal", principal.ID, "error", err)
+		return nil, err
+	}
+
+	output, found, err := s.usecase.Latest(ctx, "climate_data", in.Type, in.AreaId)
+	if err != nil {
+		logger.Error("error reading latest", "area", in.AreaId, "error", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "no data for area %s", in.AreaId)
+	}
+	return cell(output), nil
+}
+
+func (s *ClimateGRPCServer) Write(stream climatepb.ClimateService_WriteServer) error {
+	ctx := stream.Context()
+	logger := logging.FromContext(ctx).With("method", "Write")
+	principal := auth.PrincipalFromContext(ctx)
+
+	var written int64
+	batch := make([]entity.BigtableInput, 0, writeBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.usecase.Write(ctx, "climate_data", batch); err != nil {
+			logger.Error("error writing", "written", written, "error", err)
+			return status.Error(codes.Internal, err.Error())
+		}
+		written += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		key, err := entity.ParseKey(in.Key)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid key %q: %v", in.Key, err)
+		}
+		if err := authorize(principal, auth.OperationWrite, key.DataType, []string{key.AreaID}); err != nil {
+			logger.Warn("error authorizing write", "principal", principal.ID, "key", in.Key, "error", err)
+			return err
+		}
+		if _, err := entity.DecodePayload(string(in.Value)); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid value of %s: %v", in.Key, err)
+		}
+		input := entity.BigtableInput{Key: in.Key, Value: string(in.Value)}
+		if in.Created != nil {
+			input.Created = in.Created.AsTime()
+		}
+		if batch = append(batch, input); len(batch) == writeBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	logger.Info("Write successful", "written", written)
+	return stream.SendAndClose(&climatepb.WriteResponse{Written: written})
+}
+
+// authorize checks the operation, datatype and areas of a call, answering
+// PermissionDenied. A nil principal, without authentication, is allowed.
+func authorize(principal *auth.Principal, op auth.Operation, dataType string, areas []string) error {
+	if principal == nil {
+		return nil
+	}
+	if !principal.AllowsOperation(op) {
+		return status.Error(codes.PermissionDenied, "operation "+string(op)+" is not allowed")
+	}
+	if err := principal.Authorize(dataType, areas); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+func cell(output entity.BigtableOutput) *climatepb.Cell {
+	return &climatepb.Cell{
+		Key:     output.Key,
+		Created: timestamppb.New(output.Created),
+		Value:   []byte(output.Value),
+	}
+}
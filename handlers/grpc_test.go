@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/climatepb"
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GRPCSuite struct {
+	suite.Suite
+	gateway *fixtureGateway
+	server  *grpc.Server
+	conn    *grpc.ClientConn
+	client  climatepb.ClimateServiceClient
+}
+
+func TestGRPCSuite(t *testing.T) {
+	suite.Run(t, new(GRPCSuite))
+}
+
+func (g *GRPCSuite) SetupTest() {
+	g.gateway = &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	g.gateway.add("w/A327734/2023-10-10 00:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 28.79})
+	g.gateway.add("w/A327734/2023-10-10 01:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 27.5})
+	g.gateway.add("w/A327735/2023-10-10 00:00:00", created, [2]float64{-50.9, -17.8}, map[string]float64{"temperatureInst": 30})
+	// an area without a key in the last year
+	g.gateway.add("w/A327736/2022-10-10 00:00:00", created, [2]float64{4.35, 50.8}, map[string]float64{"temperatureInst": 12})
+	// forecasts of the current hour, issued twice, and of the next hours
+	for _, issued := range []time.Time{created.Add(-12 * time.Hour), created.Add(-6 * time.Hour)} {
+		g.gateway.add("f/A327734/2023-10-11 03:00:00", issued, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": float64(issued.Hour())})
+	}
+	g.gateway.add("f/A327734/2023-10-11 04:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 22})
+	g.gateway.add("f/A327734/2023-10-14 00:00:00", created, [2]float64{-50.59667, -17.749189}, map[string]float64{"temperatureInst": 25})
+
+	keysFile := filepath.Join(g.T().TempDir(), "keys.json")
+	g.Require().Nil(os.WriteFile(keysFile, []byte(`{"keys": [
+		{"id": "reader", "hash": "`+auth.HashKey("read-key")+`", "scopes": {"areas": ["A327734"]}},
+		{"id": "ingestion", "hash": "`+auth.HashKey("write-key")+`", "scopes": {"operations": ["read", "write"], "datatypes": ["w"]}}
+	]}`), 0600))
+	keyStore, err := auth.NewKeyStore(keysFile)
+	g.Require().Nil(err)
+
+	listener := bufconn.Listen(1 << 20)
+	climateUsecase := usecase.NewClimateUsecase(g.gateway)
+	climateUsecase.Now = func() time.Time { return created }
+	g.server = router.InitializeGRPC(handlers.NewClimateGRPCServer(climateUsecase),
+		[]grpc.UnaryServerInterceptor{auth.UnaryInterceptor(keyStore, nil)},
+		[]grpc.StreamServerInterceptor{auth.StreamInterceptor(keyStore, nil)})
+	go g.server.Serve(listener)
+	g.conn, err = grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	g.Require().Nil(err)
+	g.client = climatepb.NewClimateServiceClient(g.conn)
+}
+
+func (g *GRPCSuite) TearDownTest() {
+	g.conn.Close()
+	g.server.Stop()
+}
+
+func (g *GRPCSuite) ctx(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func (g *GRPCSuite) read(ctx context.Context, req *climatepb.ReadClimateDataRequest) ([]*climatepb.Cell, error) {
+	stream, err := g.client.ReadClimateData(ctx, req)
+	g.Require().Nil(err)
+	var cells []*climatepb.Cell
+	for {
+		cell, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return cells, nil
+		}
+		if err != nil {
+			return cells, err
+		}
+		cells = append(cells, cell)
+	}
+}
+
+func (g *GRPCSuite) TestReadClimateData() {
+	cells, err := g.read(g.ctx("read-key"), &climatepb.ReadClimateDataRequest{Type: "w", AreaIds: []string{"A327734"}, Dates: []string{"2023-10-10T00:00:00Z", "2023-10-10T01:00:00Z"}, InclusiveEnd: true})
+	g.Require().Nil(err)
+	g.Require().Len(cells, 2)
+	g.Equal("w/A327734/2023-10-10 00:00:00", cells[0].Key)
+	g.Equal(time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC), cells[0].Created.AsTime())
+	g.JSONEq(`{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":28.79}}`, string(cells[0].Value))
+
+	cells, err = g.read(g.ctx("read-key"), &climatepb.ReadClimateDataRequest{Type: "w", AreaIds: []string{"A327734"}})
+	g.Require().Nil(err)
+	g.Len(cells, 2)
+}
+
+func (g *GRPCSuite) TestReadErrors() {
+	_, err := g.read(g.ctx("read-key"), &climatepb.ReadClimateDataRequest{Type: "x", AreaIds: []string{"327734"}})
+	g.Equal(codes.InvalidArgument, status.Code(err))
+	g.Contains(status.Convert(err).Message(), "invalid area ID")
+
+	_, err = g.read(g.ctx("read-key"), &climatepb.ReadClimateDataRequest{Type: "w", AreaIds: []string{"A327735"}})
+	g.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = g.read(g.ctx("wrong-key"), &climatepb.ReadClimateDataRequest{Type: "w"})
+	g.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (g *GRPCSuite) TestLatest() {
+	cell, err := g.client.Latest(g.ctx("read-key"), &climatepb.LatestRequest{Type: "w", AreaId: "A327734"})
+	g.Require().Nil(err)
+	g.Equal("w/A327734/2023-10-10 01:00:00", cell.Key)
+
+	_, err = g.client.Latest(g.ctx("write-key"), &climatepb.LatestRequest{Type: "w", AreaId: "A1"})
+	g.Equal(codes.NotFound, status.Code(err))
+	_, err = g.client.Latest(g.ctx("write-key"), &climatepb.LatestRequest{Type: "w", AreaId: "A327736"})
+	g.Equal(codes.NotFound, status.Code(err))
+	_, err = g.client.Latest(g.ctx("read-key"), &climatepb.LatestRequest{Type: "w", AreaId: "A327735"})
+	g.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (g *GRPCSuite) TestLatestForecast() {
+	// the forecast of the current hour last issued, not the furthest ahead
+	cell, err := g.client.Latest(g.ctx("read-key"), &climatepb.LatestRequest{Type: "f", AreaId: "A327734"})
+	g.Require().Nil(err)
+	g.Equal("f/A327734/2023-10-11 03:00:00", cell.Key)
+	g.Equal(time.Date(2023, 10, 10, 21, 0, 0, 0, time.UTC), cell.Created.AsTime())
+	g.JSONEq(`{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":21}}`, string(cell.Value))
+}
+
+func (g *GRPCSuite) TestWrite() {
+	stream, err := g.client.Write(g.ctx("write-key"))
+	g.Require().Nil(err)
+	created := time.Date(2023, 10, 12, 3, 0, 0, 0, time.UTC)
+	g.Nil(stream.Send(&climatepb.WriteRequest{Key: "w/A327734/2023-10-10 02:00:00", Value: []byte(`{"weatherData":{"temperatureInst":26}}`), Created: timestamppb.New(created)}))
+	g.Nil(stream.Send(&climatepb.WriteRequest{Key: "w/A327734/2023-10-10 00:00:00", Value: []byte(`{"weatherData":{"temperatureInst":29}}`)}))
+	resp, err := stream.CloseAndRecv()
+	g.Require().Nil(err)
+	g.Equal(int64(2), resp.Written)
+
+	cell, err := g.client.Latest(g.ctx("read-key"), &climatepb.LatestRequest{Type: "w", AreaId: "A327734"})
+	g.Require().Nil(err)
+	g.Equal("w/A327734/2023-10-10 02:00:00", cell.Key)
+	g.Equal(created, cell.Created.AsTime())
+}
+
+func (g *GRPCSuite) TestWriteErrors() {
+	cells := len(g.gateway.cells)
+	for _, test := range []struct {
+		key   string
+		req   *climatepb.WriteRequest
+		codes codes.Code
+	}{
+		{"read-key", &climatepb.WriteRequest{Key: "w/A327734/2023-10-10 02:00:00", Value: []byte(`{}`)}, codes.PermissionDenied},
+		{"write-key", &climatepb.WriteRequest{Key: "f/A327734/2023-10-10 02:00:00", Value: []byte(`{}`)}, codes.PermissionDenied},
+		{"write-key", &climatepb.WriteRequest{Key: "w/A327734", Value: []byte(`{}`)}, codes.InvalidArgument},
+		{"write-key", &climatepb.WriteRequest{Key: "w/A327734/2023-10-10 02:00:00", Value: []byte(`not json`)}, codes.InvalidArgument},
+	} {
+		stream, err := g.client.Write(g.ctx(test.key))
+		g.Require().Nil(err)
+		stream.Send(test.req)
+		_, err = stream.CloseAndRecv()
+		g.Equal(test.codes, status.Code(err), test.req.Key)
+	}
+	g.Len(g.gateway.cells, cells)
+}
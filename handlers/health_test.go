@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthHandlersSuite struct {
+	suite.Suite
+}
+
+func TestHealthHandlersSuite(t *testing.T) {
+	suite.Run(t, new(HealthHandlersSuite))
+}
+
+type healthOutput struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+func (h *HealthHandlersSuite) get(healthHandler *handlers.HealthHandler, url string) (int, healthOutput) {
+	r := router.InitializeRouter(handlers.NewClimateHandler(usecase.NewClimateUsecase(stubGateway{})), nil, healthHandler)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	h.Nil(err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out healthOutput
+	h.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (h *HealthHandlersSuite) TestReadiness() {
+	ok := handlers.HealthCheck{Name: "bigtable", Probe: func(ctx context.Context) error { return nil }}
+	failing := handlers.HealthCheck{Name: "bigtable_admin", Probe: func(ctx context.Context) error { return errors.New("permission denied") }}
+
+	code, out := h.get(handlers.NewHealthHandler(ok), "/readyz")
+	h.Equal(http.StatusOK, code)
+	h.Equal("ok", out.Checks["bigtable"].Status)
+
+	code, out = h.get(handlers.NewHealthHandler(ok, failing), "/readyz")
+	h.Equal(http.StatusServiceUnavailable, code)
+	h.Equal("failed", out.Status)
+	h.Equal("ok", out.Checks["bigtable"].Status)
+	h.Equal("permission denied", out.Checks["bigtable_admin"].Error)
+
+	// liveness does not depend on the dependencies
+	code, _ = h.get(handlers.NewHealthHandler(failing), "/healthz")
+	h.Equal(http.StatusOK, code)
+}
+
+func (h *HealthHandlersSuite) TestDrain() {
+	healthHandler := handlers.NewHealthHandler()
+	healthHandler.Drain()
+
+	code, out := h.get(healthHandler, "/readyz")
+	h.Equal(http.StatusServiceUnavailable, code)
+	h.Equal("draining", out.Status)
+
+	code, _ = h.get(healthHandler, "/healthz")
+	h.Equal(http.StatusOK, code)
+}
@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type IssuanceHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestIssuanceHandlersSuite(t *testing.T) {
+	suite.Run(t, new(IssuanceHandlersSuite))
+}
+
+func (i *IssuanceHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	lonlat := [2]float64{-50.59667, -17.749189}
+	runs := []time.Time{
+		time.Date(2023, 10, 9, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 10, 9, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 10, 8, 0, 0, 0, 0, time.UTC),
+	}
+	// the versions of each key, newest first, as Bigtable returns them
+	for _, key := range []string{"f/A327734/2023-10-10 00:00:00", "f/A327734/2023-10-10 12:00:00"} {
+		for n, run := range runs {
+			gateway.add(key, run, lonlat, map[string]float64{"temperatureInst": float64(20 + n)})
+		}
+	}
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(gateway))
+	i.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+func (i *IssuanceHandlersSuite) get(query url.Values) (int, output) {
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?"+query.Encode(), nil)
+	i.Nil(err)
+	w := httptest.NewRecorder()
+	i.router.ServeHTTP(w, req)
+	var out output
+	i.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (i *IssuanceHandlersSuite) TestLeadTime() {
+	code, out := i.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"1d"}})
+	i.Equal(http.StatusOK, code)
+	i.Len(out.Result, 2)
+
+	// 00:00 issued at least a day before is the run of 00:00 of the 9th
+	i.Equal("f/A327734/2023-10-10 00:00:00", out.Result[0].Key)
+	i.Equal(time.Date(2023, 10, 9, 0, 0, 0, 0, time.UTC), out.Result[0].Created)
+	i.Equal(24.0, *out.Result[0].LeadTimeHours)
+	// 12:00 is the run of 12:00 of the 9th
+	i.Equal(time.Date(2023, 10, 9, 12, 0, 0, 0, time.UTC), out.Result[1].Created)
+	i.Equal(24.0, *out.Result[1].LeadTimeHours)
+
+	// 00:00 issued at least 36 hours before is the run of the 8th
+	code, out = i.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10 00:00:00"}, "lead_time": {"36h"}})
+	i.Equal(http.StatusOK, code)
+	i.Len(out.Result, 1)
+	i.Equal(48.0, *out.Result[0].LeadTimeHours)
+
+	// no run is 3 days old
+	code, out = i.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"72h"}})
+	i.Equal(http.StatusOK, code)
+	i.Empty(out.Result)
+}
+
+func (i *IssuanceHandlersSuite) TestEvolution() {
+	code, out := i.get(url.Values{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10T12:00:00Z"}, "evolution": {"true"}, "tz": {"America/Sao_Paulo"}})
+	i.Equal(http.StatusOK, code)
+	i.Len(out.Result, 3)
+	var leads []float64
+	for _, cell := range out.Result {
+		leads = append(leads, *cell.LeadTimeHours)
+	}
+	// the oldest run first
+	i.Equal([]float64{60, 36, 24}, leads)
+	i.Equal("2023-10-10T09:00:00-03:00", out.Result[0].Timestamp)
+}
+
+func (i *IssuanceHandlersSuite) TestInvalidRequests() {
+	for _, query := range []url.Values{
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"1d"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"1w"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"400h"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"1d"}, "version": {"2"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "lead_time": {"1d"}, "issued_at": {"2023-10-09"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "issued_at": {"2023-10"}},
+		{"type": {"f"}, "area_id": {"A327734"}, "date": {"2023-10-10"}, "evolution": {"true"}},
+		{"type": {"f"}, "area_id": {"A327734,A327735"}, "date": {"2023-10-10 00:00:00"}, "evolution": {"true"}},
+	} {
+		code, _ := i.get(query)
+		i.Equal(http.StatusBadRequest, code, query.Encode())
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/logging"
+	"bigtable_api/ratelimit"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryRequest is the JSON body of POST /query/climate-data, the readable
+// equivalent of the /read/climate-data parameters plus the projection and the
+// aggregation of the variables.
+type QueryRequest struct {
+	Datatype string    `json:"datatype" binding:"required,oneof=w f"`
+	Areas    []string  `json:"areas" binding:"max=50,dive,areaid"`
+	Time     QueryTime `json:"time"`
+	// TZ is the timezone of the dates without an offset, of the returned
+	// timestamps and of the aggregation buckets.
+	TZ         string       `json:"tz" binding:"omitempty,timezone"`
+	Versions   int          `json:"versions" binding:"omitempty,min=1,max=100"`
+	Filters    QueryFilters `json:"filters"`
+	Projection []string     `json:"projection" binding:"max=50,dive,required"`
+	// Derived are variables computed from the payloads, kept by the
+	// projection and summarized by the aggregation.
+	Derived []string `json:"derived" binding:"max=4,dive,oneof=dewPoint vpd heatIndex windChill"`
+	// Aggregation summarizes the variables of each area per time bucket.
+	Aggregation *QueryAggregation `json:"aggregation"`
+	Format      string            `json:"format" binding:"omitempty,oneof=json csv parquet netcdf"`
+	CSV         QueryCSV          `json:"csv"`
+	Count       bool              `json:"count"`
+}
+
+// QueryTime is a date, as the date parameter, or a range from and to.
+type QueryTime struct {
+	At           string `json:"at" binding:"omitempty,date"`
+	From         string `json:"from" binding:"omitempty,date"`
+	To           string `json:"to" binding:"omitempty,date"`
+	InclusiveEnd bool   `json:"inclusive_end"`
+}
+
+type QueryFilters struct {
+	Regexp     string `json:"regexp" binding:"omitempty,max=256,re2"`
+	Expression string `json:"expression" binding:"omitempty,max=1024,filterexpr"`
+	Where      string `json:"where" binding:"omitempty,max=1024,where"`
+}
+
+type QueryAggregation struct {
+	Interval  string   `json:"interval" binding:"required,oneof=hour day week month"`
+	Functions []string `json:"functions" binding:"required,min=1,dive,oneof=avg min max sum count"`
+}
+
+type QueryCSV struct {
+	Delimiter string `json:"delimiter" binding:"omitempty,delimiter"`
+	Decimal   string `json:"decimal" binding:"omitempty,decimal"`
+}
+
+// validate checks the rules involving more than one field, those of
+// ReadClimateRequest with the names of the body.
+func (q *QueryRequest) validate() []Violation {
+	var violations []Violation
+	hasTime := q.Time.At != "" || q.Time.From != "" || q.Time.To != ""
+	if len(q.Areas) == 0 && hasTime {
+		violations = append(violations, Violation{Field: "areas", Rule: "required_with", Message: "areas is required when time is informed"})
+	}
+	if q.Time.At != "" && (q.Time.From != "" || q.Time.To != "") {
+		violations = append(violations, Violation{Field: "time.at", Rule: "excluded_with", Message: "time.at can not be combined with time.from or time.to"})
+	}
+	if q.CSV.Delimiter != "" && q.CSV.Delimiter == q.CSV.Decimal {
+		violations = append(violations, Violation{Field: "csv.delimiter", Rule: "nefield", Message: "csv.delimiter and csv.decimal must be different"})
+	} else if q.CSV.Delimiter == "" && q.CSV.Decimal == "," {
+		violations = append(violations, Violation{Field: "csv.delimiter", Rule: "required_with", Message: "csv.decimal , requires another csv.delimiter, such as ;"})
+	}
+	for _, bound := range [][2]string{{"time.from", q.Time.From}, {"time.to", q.Time.To}} {
+		field, value := bound[0], bound[1]
+		if _, prefix, err := resolveDate(value, time.UTC); err == nil && prefix && len(value) != dayPrefixLength {
+			violations = append(violations, Violation{Field: field, Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: %s requires a day (YYYY-MM-DD) or a complete date", value, field)})
+		}
+	}
+	if _, prefix, err := resolveDate(q.Time.At, time.UTC); q.Time.At != "" && err == nil && prefix {
+		if len(q.Areas) > 1 {
+			violations = append(violations, Violation{Field: "time.at", Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: reading more than one area requires a complete date", q.Time.At)})
+		} else if q.TZ != "" && len(q.Time.At) != dayPrefixLength {
+			violations = append(violations, Violation{Field: "time.at", Rule: "tz_prefix", Message: fmt.Sprintf("incomplete date %q: only whole days (YYYY-MM-DD) can be combined with tz", q.Time.At)})
+		}
+	}
+	if q.Aggregation != nil && q.Versions > 1 {
+		violations = append(violations, Violation{Field: "versions", Rule: "excluded_with", Message: "versions can not be combined with aggregation, which uses the latest version"})
+	}
+	return violations
+}
+
+// readRequest translates the query to the parameters of /read/climate-data.
+func (q *QueryRequest) readRequest() *ReadClimateRequest {
+	req := &ReadClimateRequest{
+		Type:             q.Datatype,
+		AreaIDs:          q.Areas,
+		From:             q.Time.From,
+		To:               q.Time.To,
+		Regexp:           q.Filters.Regexp,
+		Filter:           q.Filters.Expression,
+		Where:            q.Filters.Where,
+		Derived:          q.Derived,
+		TZ:               q.TZ,
+		Format:           q.Format,
+		Delimiter:        q.CSV.Delimiter,
+		DecimalSeparator: q.CSV.Decimal,
+	}
+	if q.Time.At != "" {
+		req.Dates = []string{q.Time.At}
+	}
+	if q.Time.InclusiveEnd {
+		req.InclusiveEnd = "true"
+	}
+	if q.Versions > 0 {
+		req.Version = strconv.Itoa(q.Versions)
+	}
+	return req
+}
+
+// exportVariables returns the variables of the cells of the query: the
+// projected ones, or else the weather ones, and the derived ones, each with
+// the functions of the aggregation.
+func (q *QueryRequest) exportVariables() []string {
+	variables := q.Projection
+	if len(variables) == 0 {
+		variables = entity.WeatherVariables
+	}
+	variables = append(append([]string{}, variables...), q.Derived...)
+	if q.Aggregation == nil {
+		return variables
+	}
+	aggregated := make([]string, 0, len(variables)*len(q.Aggregation.Functions))
+	for _, name := range variables {
+		for _, function := range q.Aggregation.Functions {
+			aggregated = append(aggregated, name+"_"+function)
+		}
+	}
+	return aggregated
+}
+
+// transform applies the projection and the aggregation to the cells of read.
+func (q *QueryRequest) transform(loc *time.Location, read func(func(entity.BigtableOutput) bool) error) func(func(entity.BigtableOutput) bool) error {
+	return func(fn func(entity.BigtableOutput) bool) error {
+		next := fn
+		var aggregator *usecase.Aggregator
+		if q.Aggregation != nil {
+			aggregator = usecase.NewAggregator(q.Aggregation.Interval, q.Aggregation.Functions, loc, fn)
+			next = aggregator.Add
+		}
+		if len(q.Projection) > 0 {
+			project := next
+			variables := append(append([]string{}, q.Projection...), q.Derived...)
+			next = func(output entity.BigtableOutput) bool {
+				if value, err := entity.ProjectPayload(output.Value, variables); err == nil {
+					output.Value = value
+				}
+				return project(output)
+			}
+		}
+		if err := read(next); err != nil {
+			return err
+		}
+		if aggregator != nil {
+			aggregator.Flush()
+		}
+		return nil
+	}
+}
+
+// QueryClimateData reads the climate data described by a JSON body.
+func (h *ClimateHandler) QueryClimateData(ctx *gin.Context) {
+	start := time.Now()
+	logger := logging.FromContext(ctx)
+
+	var query QueryRequest
+	if err := decodeBody(ctx, &query); err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+	body, _ := json.Marshal(query)
+	logger = logger.With("query", string(body))
+
+	req, read, err := h.prepareQuery(ctx, &query)
+	var forbidden *forbiddenError
+	if errors.As(err, &forbidden) {
+		logger.Warn("error authorizing request", "principal", auth.PrincipalFrom(ctx).ID, "error", err)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	if err != nil {
+		abortValidation(ctx, logger, err)
+		return
+	}
+
+	if format := req.format(ctx); format != "json" {
+		h.writeExport(ctx, logger, format, req, query.exportVariables(), read)
+		return
+	}
+
+	output, err := collect(req, read)
+	if err != nil {
+		logger.Error("error reading query", "error", err)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	result := gin.H{"result": output, "status": "success"}
+	if query.Count {
+		result["count"] = len(output)
+	}
+	ratelimit.RecordRows(ctx, len(output))
+	logger.Info("Request successful", "rows", len(output), "duration", time.Since(start))
+	ctx.JSON(http.StatusOK, result)
+}
+
+// forbiddenError is the error of a query the principal can not read.
+type forbiddenError struct {
+	err error
+}
+
+func (e *forbiddenError) Error() string { return e.err.Error() }
+
+func (e *forbiddenError) Unwrap() error { return e.err }
+
+// decodeBody decodes a JSON body into req, rejecting unknown fields, and
+// validates it.
+func decodeBody(ctx *gin.Context, req interface{ validate() []Violation }) error {
+	if err := decodeJSON(ctx, req); err != nil {
+		return err
+	}
+	return validateRequest(req)
+}
+
+func decodeJSON(ctx *gin.Context, v interface{}) error {
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return &ValidationError{Violations: []Violation{{Field: "body", Rule: "json", Message: err.Error()}}}
+	}
+	return nil
+}
+
+// prepareQuery authorizes a validated query, returning its read parameters and
+// the read of its cells, already projected and aggregated. It fails with a
+// *ValidationError for dates that can not be converted, and with a
+// *forbiddenError when the principal can not read the query.
+func (h *ClimateHandler) prepareQuery(ctx *gin.Context, query *QueryRequest) (*ReadClimateRequest, func(func(entity.BigtableOutput) bool) error, error) {
+	req := query.readRequest()
+	dates, err := req.keyDates()
+	if err != nil {
+		return nil, nil, err
+	}
+	principal := auth.PrincipalFrom(ctx)
+	if err := principal.Authorize(req.Type, req.AreaIDs); err != nil {
+		return nil, nil, &forbiddenError{err: err}
+	}
+	return req, query.transform(req.location(), h.reader(ctx, req, dates, principal)), nil
+}
+
+// collect reads every cell of read, localized to the timezone of req.
+func collect(req *ReadClimateRequest, read func(func(entity.BigtableOutput) bool) error) ([]entity.BigtableOutput, error) {
+	output := []entity.BigtableOutput{}
+	err := read(func(cell entity.BigtableOutput) bool {
+		output = append(output, cell)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.TZ != "" {
+		localize(output, req.location())
+	}
+	return output, nil
+}
@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueryHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestQueryHandlersSuite(t *testing.T) {
+	suite.Run(t, new(QueryHandlersSuite))
+}
+
+func (q *QueryHandlersSuite) SetupTest() {
+	gateway := &fixtureGateway{}
+	created := time.Date(2023, 10, 11, 3, 0, 0, 0, time.UTC)
+	lonlat := [2]float64{-50.59667, -17.749189}
+	gateway.add("w/A327734/2023-10-09 23:00:00", created, lonlat, map[string]float64{"temperatureInst": 30, "humidityInst": 60, "rain": 0})
+	gateway.add("w/A327734/2023-10-10 00:00:00", created, lonlat, map[string]float64{"temperatureInst": 28, "humidityInst": 60, "rain": 0.5})
+	gateway.add("w/A327734/2023-10-10 01:00:00", created, lonlat, map[string]float64{"temperatureInst": 26, "humidityInst": 60, "rain": 1.5})
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(gateway))
+	q.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+func (q *QueryHandlersSuite) post(body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodPost, "/query/climate-data", strings.NewReader(body))
+	q.Nil(err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	q.router.ServeHTTP(w, req)
+	return w
+}
+
+func (q *QueryHandlersSuite) TestProjection() {
+	w := q.post(`{"datatype":"w","areas":["A327734"],"time":{"from":"2023-10-10"},"projection":["rain"],"count":true}`)
+	q.Equal(http.StatusOK, w.Code)
+	var out output
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.Equal(2, out.Count)
+	q.Equal("w/A327734/2023-10-10 00:00:00", out.Result[0].Key)
+	q.JSONEq(`{"lonlat":[-50.59667,-17.749189],"weatherData":{"rain":0.5}}`, out.Result[0].Value)
+}
+
+func (q *QueryHandlersSuite) TestDailyAggregation() {
+	// in Sao Paulo the three hours are the 9th and the 10th of October
+	w := q.post(`{
+		"datatype": "w",
+		"areas": ["A327734"],
+		"tz": "America/Sao_Paulo",
+		"aggregation": {"interval": "day", "functions": ["avg", "max", "sum"]},
+		"projection": ["temperatureInst", "rain"]
+	}`)
+	q.Equal(http.StatusOK, w.Code)
+	var out output
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.Len(out.Result, 1)
+	q.Equal("w/A327734/2023-10-09 03:00:00", out.Result[0].Key)
+	q.Equal("2023-10-09T00:00:00-03:00", out.Result[0].Timestamp)
+	q.JSONEq(`{"aggregates":{
+		"temperatureInst_avg":28,"temperatureInst_max":30,"temperatureInst_sum":84,
+		"rain_avg":0.6666666666666666,"rain_max":1.5,"rain_sum":2
+	},"lonlat":[-50.59667,-17.749189]}`, out.Result[0].Value)
+
+	w = q.post(`{"datatype":"w","areas":["A327734"],"aggregation":{"interval":"day","functions":["count"]}}`)
+	q.Equal(http.StatusOK, w.Code)
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.Len(out.Result, 2)
+}
+
+func (q *QueryHandlersSuite) TestDerived() {
+	// the derived variables are kept by the projection
+	w := q.post(`{"datatype":"w","areas":["A327734"],"time":{"at":"2023-10-10 00:00:00"},"projection":["rain"],"derived":["dewPoint","windChill"]}`)
+	q.Equal(http.StatusOK, w.Code)
+	var out output
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.JSONEq(`{"lonlat":[-50.59667,-17.749189],"weatherData":{"rain":0.5,"dewPoint":19.51}}`, out.Result[0].Value)
+
+	// and summarized by the aggregation
+	w = q.post(`{"datatype":"w","areas":["A327734"],"derived":["vpd"],"projection":[],"aggregation":{"interval":"month","functions":["max"]}}`)
+	q.Equal(http.StatusOK, w.Code)
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.Contains(out.Result[0].Value, `"vpd_max":1.7`)
+
+	w = q.post(`{"datatype":"w","derived":["feelsLike"]}`)
+	q.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (q *QueryHandlersSuite) TestReadDerived() {
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?type=w&area_id=A327734&date=2023-10-10&derived=vpd,heatIndex", nil)
+	q.Nil(err)
+	w := httptest.NewRecorder()
+	q.router.ServeHTTP(w, req)
+	q.Equal(http.StatusOK, w.Code)
+	var out output
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	q.Len(out.Result, 2)
+	q.JSONEq(`{"lonlat":[-50.59667,-17.749189],"weatherData":{"temperatureInst":26,"humidityInst":60,"rain":1.5,"vpd":1.34,"heatIndex":26.22}}`, out.Result[1].Value)
+}
+
+func (q *QueryHandlersSuite) TestCSV() {
+	w := q.post(`{"datatype":"w","areas":["A327734"],"time":{"at":"2023-10-10 01:00:00"},"projection":["rain"],"format":"csv","csv":{"delimiter":";"}}`)
+	q.Equal(http.StatusOK, w.Code)
+	q.Equal(strings.Join([]string{
+		"datatype;area_id;timestamp;created;lon;lat;rain",
+		"w;A327734;2023-10-10T01:00:00Z;2023-10-11T03:00:00Z;-50.59667;-17.749189;1.5",
+	}, "\n")+"\n", w.Body.String())
+}
+
+func (q *QueryHandlersSuite) TestAggregatedCSV() {
+	w := q.post(`{"datatype":"w","areas":["A327734"],"projection":["rain"],"aggregation":{"interval":"day","functions":["sum","max"]},"format":"csv"}`)
+	q.Equal(http.StatusOK, w.Code)
+	q.Equal(strings.Join([]string{
+		"datatype,area_id,timestamp,created,lon,lat,rain_sum,rain_max",
+		"w,A327734,2023-10-09T00:00:00Z,2023-10-11T03:00:00Z,-50.59667,-17.749189,0,0",
+		"w,A327734,2023-10-10T00:00:00Z,2023-10-11T03:00:00Z,-50.59667,-17.749189,2,1.5",
+	}, "\n")+"\n", w.Body.String())
+}
+
+func (q *QueryHandlersSuite) TestViolations() {
+	w := q.post(`{
+		"datatype": "x",
+		"time": {"at": "2023-10-10", "from": "2023-10"},
+		"versions": 2,
+		"aggregation": {"interval": "year", "functions": []}
+	}`)
+	q.Equal(http.StatusBadRequest, w.Code)
+	var out validationOutput
+	q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	var rules []string
+	for _, violation := range out.Violations {
+		rules = append(rules, violation.Field+":"+violation.Rule)
+	}
+	q.ElementsMatch([]string{
+		"datatype:oneof",
+		"aggregation.interval:oneof",
+		"aggregation.functions:min",
+		"areas:required_with",
+		"time.at:excluded_with",
+		"time.from:complete_date",
+		"versions:excluded_with",
+	}, rules)
+}
+
+func (q *QueryHandlersSuite) TestMalformedBody() {
+	for _, body := range []string{`{"datatype":"w","area_id":["A327734"]}`, `{"datatype":`, `[]`} {
+		w := q.post(body)
+		q.Equal(http.StatusBadRequest, w.Code, body)
+		var out validationOutput
+		q.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+		q.Equal("body:json", out.Violations[0].Field+":"+out.Violations[0].Rule, body)
+	}
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/entity"
+	"bigtable_api/filterexpr"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxVersions bounds the cells returned per key.
+const MaxVersions = 100
+
+var areaIDPattern = regexp.MustCompile(`^A[0-9]+$`)
+
+// ReadClimateRequest holds the query parameters of /read/climate-data. It
+// reads at most 50 areas, and regexps are capped to 256 characters.
+type ReadClimateRequest struct {
+	Type    string   `form:"type" binding:"required,oneof=w f"`
+	AreaIDs []string `form:"area_id" binding:"max=50,dive,areaid"`
+	Dates   []string `form:"date" binding:"max=2,dive,date"`
+	// From and To are the open-ended alternative to a range of dates.
+	From         string `form:"from" binding:"omitempty,date"`
+	To           string `form:"to" binding:"omitempty,date"`
+	InclusiveEnd string `form:"inclusive_end" binding:"omitempty,oneof=true false"`
+	Version      string `form:"version" binding:"omitempty,versions"`
+	Regexp       string `form:"regexp" binding:"omitempty,max=256,re2"`
+	// Filter is a filterexpr expression on the keys, columns and cells.
+	Filter string `form:"filter" binding:"omitempty,max=1024,filterexpr"`
+	// Where is a predicate on the payload variables, such as rain > 0.
+	Where string `form:"where" binding:"omitempty,max=1024,where"`
+	Count string `form:"count" binding:"omitempty,oneof=true false"`
+	// TZ is the IANA timezone of dates without an offset and of the
+	// returned timestamps. The key timezone by default.
+	TZ string `form:"tz" binding:"omitempty,timezone"`
+	// IssuedAt selects, for each forecast key, the version issued at that
+	// time, and LeadTime the version issued that long before the key date.
+	// Evolution returns every version of a single forecast key, oldest first.
+	IssuedAt  string `form:"issued_at" binding:"omitempty,date"`
+	LeadTime  string `form:"lead_time" binding:"omitempty,lead_time"`
+	Evolution string `form:"evolution" binding:"omitempty,oneof=true false"`
+	// Derived are variables computed from the payloads, see meteo.
+	Derived []string `form:"derived" binding:"max=4,dive,oneof=dewPoint vpd heatIndex windChill"`
+	// Format is json by default, or the format of the Accept header.
+	Format           string `form:"format" binding:"omitempty,oneof=json csv parquet netcdf"`
+	Delimiter        string `form:"delimiter" binding:"omitempty,delimiter"`
+	DecimalSeparator string `form:"decimal" binding:"omitempty,decimal"`
+}
+
+// Violation is a parameter that failed validation.
+type Violation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every violation of a request at once.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func init() {
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			if name, _, _ := strings.Cut(field.Tag.Get("form"), ","); name != "" {
+				return name
+			}
+			if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+				return name
+			}
+			return field.Name
+		})
+		engine.RegisterValidation("areaid", func(fl validator.FieldLevel) bool {
+			return areaIDPattern.MatchString(fl.Field().String())
+		})
+		engine.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+			_, _, err := resolveDate(fl.Field().String(), time.UTC)
+			return err == nil
+		})
+		engine.RegisterValidation("versions", func(fl validator.FieldLevel) bool {
+			version, err := strconv.Atoi(fl.Field().String())
+			return err == nil && version >= 1 && version <= MaxVersions
+		})
+		engine.RegisterValidation("delimiter", func(fl validator.FieldLevel) bool {
+			delimiter := []rune(fl.Field().String())
+			return len(delimiter) == 1 && !strings.ContainsRune("\"\r\n.", delimiter[0])
+		})
+		engine.RegisterValidation("decimal", func(fl validator.FieldLevel) bool {
+			decimal := fl.Field().String()
+			return decimal == "." || decimal == ","
+		})
+		engine.RegisterValidation("re2", func(fl validator.FieldLevel) bool {
+			_, err := regexp.Compile(fl.Field().String())
+			return err == nil
+		})
+		engine.RegisterValidation("filterexpr", func(fl validator.FieldLevel) bool {
+			_, err := filterexpr.Parse(fl.Field().String(), entity.KeyLocation)
+			return err == nil
+		})
+		engine.RegisterValidation("where", func(fl validator.FieldLevel) bool {
+			_, err := filterexpr.ParseWhere(fl.Field().String())
+			return err == nil
+		})
+		engine.RegisterValidation("lead_time", func(fl validator.FieldLevel) bool {
+			_, err := parseLeadTime(fl.Field().String())
+			return err == nil
+		})
+		engine.RegisterValidation("interval", func(fl validator.FieldLevel) bool {
+			_, err := resolveInterval(fl.Field().String(), time.UTC)
+			return err == nil
+		})
+		engine.RegisterValidation("wkt_point", func(fl validator.FieldLevel) bool {
+			return validPoint(fl.Field().String())
+		})
+	}
+}
+
+// bindQuery binds and validates the query parameters into req, returning a
+// *ValidationError with every violation found. Slice fields take comma
+// separated values, as in area_id=A1,A2.
+func bindQuery(ctx *gin.Context, req interface{ validate() []Violation }) error {
+	query := ctx.Request.URL.Query()
+	reqType := reflect.TypeOf(req).Elem()
+	for i := 0; i < reqType.NumField(); i++ {
+		field := reqType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if values, ok := query[name]; ok && field.Type.Kind() == reflect.Slice {
+			var split []string
+			for _, value := range values {
+				if value != "" {
+					split = append(split, strings.Split(value, ",")...)
+				}
+			}
+			query[name] = split
+		}
+	}
+	if err := binding.MapFormWithTag(req, query, "form"); err != nil {
+		return &ValidationError{Violations: []Violation{{Field: "query", Rule: "format", Message: err.Error()}}}
+	}
+	return validateRequest(req)
+}
+
+// validateRequest checks the binding rules and the cross-field ones of req.
+func validateRequest(req interface{ validate() []Violation }) error {
+	var violations []Violation
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return err
+		}
+		violations = append(violations, translate(validationErrors)...)
+	}
+	violations = append(violations, req.validate()...)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validate checks the rules involving more than one parameter.
+func (r *ReadClimateRequest) validate() []Violation {
+	var violations []Violation
+	if len(r.AreaIDs) == 0 && (len(r.Dates) > 0 || r.From != "" || r.To != "") {
+		violations = append(violations, Violation{Field: "area_id", Rule: "required_with", Message: "area_id is required when date, from or to is informed"})
+	}
+	if len(r.Dates) > 0 && (r.From != "" || r.To != "") {
+		violations = append(violations, Violation{Field: "date", Rule: "excluded_with", Message: "date can not be combined with from or to"})
+	}
+	if r.Delimiter != "" && r.Delimiter == r.DecimalSeparator {
+		violations = append(violations, Violation{Field: "delimiter", Rule: "nefield", Message: "delimiter and decimal must be different"})
+	} else if r.Delimiter == "" && r.DecimalSeparator == "," {
+		violations = append(violations, Violation{Field: "delimiter", Rule: "required_with", Message: "decimal=, requires another delimiter, such as ;"})
+	}
+	for _, bound := range [][2]string{{"from", r.From}, {"to", r.To}} {
+		field, value := bound[0], bound[1]
+		// a day stands for its first second
+		if _, prefix, err := resolveDate(value, time.UTC); err == nil && prefix && len(value) != dayPrefixLength {
+			violations = append(violations, Violation{Field: field, Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: %s requires a day (YYYY-MM-DD) or a complete date", value, field)})
+		}
+	}
+	violations = append(violations, r.validateIssuance()...)
+	for _, date := range r.Dates {
+		_, prefix, err := resolveDate(date, time.UTC)
+		if err != nil || !prefix {
+			continue
+		}
+		// only the single area prefix path accepts incomplete dates
+		if len(r.AreaIDs) > 1 || len(r.Dates) > 1 {
+			violations = append(violations, Violation{Field: "date", Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: reading more than one area or a range of dates requires a complete date", date)})
+		} else if r.TZ != "" && len(date) != dayPrefixLength {
+			violations = append(violations, Violation{Field: "date", Rule: "tz_prefix", Message: fmt.Sprintf("incomplete date %q: only whole days (YYYY-MM-DD) can be combined with tz", date)})
+		}
+	}
+	return violations
+}
+
+// validateIssuance checks the selection of the forecasts by issuance.
+func (r *ReadClimateRequest) validateIssuance() []Violation {
+	var violations []Violation
+	selections := 0
+	for _, selection := range []struct{ field, value string }{
+		{"issued_at", r.IssuedAt}, {"lead_time", r.LeadTime}, {"evolution", r.Evolution},
+	} {
+		if selection.value == "" || selection.value == "false" {
+			continue
+		}
+		selections++
+		if r.Type != "f" {
+			violations = append(violations, Violation{Field: selection.field, Rule: "required_with", Message: selection.field + " requires type=f"})
+		}
+		if r.Version != "" {
+			violations = append(violations, Violation{Field: selection.field, Rule: "excluded_with", Message: selection.field + " can not be combined with version"})
+		}
+	}
+	if selections > 1 {
+		violations = append(violations, Violation{Field: "issued_at", Rule: "excluded_with", Message: "only one of issued_at, lead_time and evolution can be informed"})
+	}
+	if _, prefix, err := resolveDate(r.IssuedAt, time.UTC); r.IssuedAt != "" && err == nil && prefix && len(r.IssuedAt) != dayPrefixLength {
+		violations = append(violations, Violation{Field: "issued_at", Rule: "complete_date", Message: fmt.Sprintf("incomplete date %q: issued_at requires a day (YYYY-MM-DD) or a complete date", r.IssuedAt)})
+	}
+	if r.Evolution == "true" {
+		_, prefix, err := resolveDate(firstOrEmpty(r.Dates), time.UTC)
+		if len(r.AreaIDs) != 1 || len(r.Dates) != 1 || err != nil || prefix {
+			violations = append(violations, Violation{Field: "evolution", Rule: "required_with", Message: "evolution requires a single area_id and a single complete date"})
+		}
+	}
+	return violations
+}
+
+// selectsIssuance reports whether the forecasts are selected by issuance.
+func (r *ReadClimateRequest) selectsIssuance() bool {
+	return r.IssuedAt != "" || r.LeadTime != "" || r.Evolution == "true"
+}
+
+// transformsCells reports whether the cells read are changed or selected
+// before being returned, by reader.
+func (r *ReadClimateRequest) transformsCells() bool {
+	return r.selectsIssuance() || len(r.Derived) > 0
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// exportVariables returns the variables of the cells of the request: the
+// weather ones, then the derived ones.
+func (r *ReadClimateRequest) exportVariables() []string {
+	return append(append([]string{}, entity.WeatherVariables...), r.Derived...)
+}
+
+// filters returns the repository filters of the request.
+func (r *ReadClimateRequest) filters() map[string]string {
+	filters := make(map[string]string)
+	if r.Version != "" {
+		filters["version"] = r.Version
+	}
+	if r.Regexp != "" {
+		filters["regexp"] = r.Regexp
+	}
+	if r.Filter != "" {
+		filters["filter"] = r.Filter
+		// the dates of the expression without an offset are in tz
+		if r.TZ != "" {
+			filters["tz"] = r.TZ
+		}
+	}
+	if r.Where != "" {
+		filters["where"] = r.Where
+	}
+	if r.InclusiveEnd == "true" {
+		filters["inclusive_end"] = "true"
+	}
+	if r.IssuedAt != "" {
+		if date, err := resolveBound(r.IssuedAt, r.location()); err == nil {
+			if issued, err := entity.ParseKeyDate(date); err == nil {
+				filters["issued_at"] = issued.Format(time.RFC3339Nano)
+			}
+		}
+	}
+	// the version of the lead time, or the evolution, is among all of them
+	if r.LeadTime != "" || r.Evolution == "true" {
+		filters["version"] = strconv.Itoa(MaxVersions)
+	}
+	return filters
+}
+
+// prefixes returns the parts of the key prefix read for a single area and at
+// most one date.
+func (r *ReadClimateRequest) prefixes(dates []string, principal *auth.Principal) []string {
+	prefixes := []string{r.Type}
+	if len(r.AreaIDs) == 1 {
+		area := r.AreaIDs[0]
+		// an area restricted principal can not use the area as a prefix of other areas
+		if principal != nil && principal.RestrictsAreas() && len(dates) == 0 {
+			area += "/"
+		}
+		prefixes = append(prefixes, area)
+		if len(dates) == 1 {
+			prefixes = append(prefixes, dates[0])
+		}
+	}
+	return prefixes
+}
+
+// location returns the timezone of the request.
+func (r *ReadClimateRequest) location() *time.Location {
+	if r.TZ == "" {
+		return entity.KeyLocation
+	}
+	loc, err := time.LoadLocation(r.TZ)
+	if err != nil {
+		return entity.KeyLocation
+	}
+	return loc
+}
+
+const (
+	mimeCSV     = "text/csv"
+	mimeParquet = "application/vnd.apache.parquet"
+	mimeNetCDF  = "application/x-netcdf"
+)
+
+// format returns the requested output format, from the format parameter or
+// else from the Accept header.
+func (r *ReadClimateRequest) format(ctx *gin.Context) string {
+	if r.Format != "" {
+		return r.Format
+	}
+	switch ctx.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimeParquet, mimeNetCDF) {
+	case mimeCSV:
+		return "csv"
+	case mimeParquet:
+		return "parquet"
+	case mimeNetCDF:
+		return "netcdf"
+	}
+	return "json"
+}
+
+// keyDates converts the validated dates to the date part of the row keys. A
+// day in a timezone other than the key one becomes the range of keys it covers.
+// From and to become a range whose missing end is an empty date.
+func (r *ReadClimateRequest) keyDates() ([]string, error) {
+	loc := r.location()
+	if r.From != "" || r.To != "" {
+		from, err := resolveBound(r.From, loc)
+		if err != nil {
+			return nil, err
+		}
+		to, err := resolveBound(r.To, loc)
+		if err != nil {
+			return nil, err
+		}
+		return []string{from, to}, nil
+	}
+	dates := make([]string, 0, len(r.Dates))
+	for _, value := range r.Dates {
+		date, prefix, err := resolveDate(value, loc)
+		if err != nil {
+			return nil, err
+		}
+		if prefix && loc != entity.KeyLocation {
+			return dayRange(date, loc)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func translate(validationErrors validator.ValidationErrors) []Violation {
+	violations := make([]Violation, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		// the namespace names the fields of nested structs, as time.from
+		_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+		var message string
+		switch fieldError.Tag() {
+		case "required":
+			message = field + " is required"
+		case "oneof":
+			message = fmt.Sprintf("%s must be one of: %s", field, fieldError.Param())
+		case "min":
+			if fieldError.Kind() == reflect.Slice {
+				message = fmt.Sprintf("%s requires at least %s values", field, fieldError.Param())
+			} else {
+				message = fmt.Sprintf("%s must be at least %s", field, fieldError.Param())
+			}
+		case "max":
+			switch fieldError.Kind() {
+			case reflect.Slice:
+				message = fmt.Sprintf("%s accepts at most %s values", field, fieldError.Param())
+			case reflect.String:
+				message = fmt.Sprintf("%s must have at most %s characters", field, fieldError.Param())
+			default:
+				message = fmt.Sprintf("%s must be at most %s", field, fieldError.Param())
+			}
+		case "areaid":
+			message = fmt.Sprintf("invalid area ID %q: expected 'A' followed by digits", fieldError.Value())
+		case "date":
+			message = fmt.Sprintf("invalid date %q: expected ISO-8601, the layout %s or a prefix of it", fieldError.Value(), entity.KeyDateLayout)
+		case "datetime":
+			message = fmt.Sprintf("invalid date %q: expected the layout %s", fieldError.Value(), fieldError.Param())
+		case "timezone":
+			message = fmt.Sprintf("invalid timezone %q: expected an IANA name such as America/Sao_Paulo", fieldError.Value())
+		case "versions":
+			message = fmt.Sprintf("invalid version %q: expected a number between 1 and %d", fieldError.Value(), MaxVersions)
+		case "delimiter":
+			message = fmt.Sprintf("invalid delimiter %q: expected a single character other than a quote, a dot or a line break", fieldError.Value())
+		case "decimal":
+			message = fmt.Sprintf("invalid decimal separator %q: expected . or ,", fieldError.Value())
+		case "re2":
+			message = fmt.Sprintf("invalid regexp %q: expected RE2 syntax", fieldError.Value())
+		case "filterexpr":
+			_, err := filterexpr.Parse(fieldError.Value().(string), entity.KeyLocation)
+			message = fmt.Sprintf("invalid filter %q: %v", fieldError.Value(), err)
+		case "where":
+			_, err := filterexpr.ParseWhere(fieldError.Value().(string))
+			message = fmt.Sprintf("invalid where %q: %v", fieldError.Value(), err)
+		case "lead_time":
+			message = fmt.Sprintf("invalid lead time %q: expected hours or days up to 16d, such as 36h or 2d", fieldError.Value())
+		case "interval":
+			message = fmt.Sprintf("invalid datetime %q: expected an ISO-8601 instant or interval, such as 2023-10-01T00:00:00Z/..", fieldError.Value())
+		case "wkt_point":
+			message = fmt.Sprintf("invalid coords %q: expected POINT(lon lat)", fieldError.Value())
+		default:
+			message = fmt.Sprintf("%s failed the %s rule", field, fieldError.Tag())
+		}
+		violations = append(violations, Violation{Field: field, Rule: fieldError.Tag(), Message: message})
+	}
+	return violations
+}
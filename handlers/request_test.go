@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidationHandlersSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestValidationHandlersSuite(t *testing.T) {
+	suite.Run(t, new(ValidationHandlersSuite))
+}
+
+func (v *ValidationHandlersSuite) SetupTest() {
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(stubGateway{}))
+	v.router = router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+}
+
+type validationOutput struct {
+	Status     string               `json:"status"`
+	Violations []handlers.Violation `json:"violations"`
+}
+
+func (v *ValidationHandlersSuite) get(query url.Values) (int, validationOutput) {
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?"+query.Encode(), nil)
+	v.Nil(err)
+	w := httptest.NewRecorder()
+	v.router.ServeHTTP(w, req)
+	var out validationOutput
+	v.Nil(json.Unmarshal(w.Body.Bytes(), &out))
+	return w.Code, out
+}
+
+func (v *ValidationHandlersSuite) rules(out validationOutput) []string {
+	var rules []string
+	for _, violation := range out.Violations {
+		rules = append(rules, violation.Field+":"+violation.Rule)
+	}
+	return rules
+}
+
+func (v *ValidationHandlersSuite) TestValidRequests() {
+	for _, query := range []url.Values{
+		{"type": {"w"}},
+		{"type": {"f"}, "area_id": {"A3277"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "date": {"2023-10"}, "version": {"2"}, "regexp": {".*00:00"}, "count": {"true"}},
+		{"type": {"w"}, "area_id": {"A327734,A327735"}, "date": {"2023-10-10 00:00:00,2023-10-11 00:00:00"}},
+		{"type": {"w"}, "area_id": {"A327734"}, "filter": {`key ~ ".*00:00" AND created > 2023-10-01 AND NOT family = "raw"`}},
+		{"type": {"w"}, "area_id": {"A327734"}, "where": {"rain > 0 OR temperatureMin < 2"}, "count": {"true"}},
+	} {
+		code, out := v.get(query)
+		v.Equal(http.StatusOK, code, query.Encode())
+		v.Empty(out.Violations)
+	}
+}
+
+func (v *ValidationHandlersSuite) TestAllViolationsReported() {
+	code, out := v.get(url.Values{
+		"type":    {"x"},
+		"area_id": {"A327734,327735"},
+		"date":    {"2023-10-10,2023-13-10 00:00:00"},
+		"version": {"0"},
+		"regexp":  {"(unclosed"},
+		"count":   {"yes"},
+		"filter":  {"created ~ 2023"},
+		"where":   {"rain ~ 0"},
+	})
+	v.Equal(http.StatusBadRequest, code)
+	v.Equal("failed", out.Status)
+	v.ElementsMatch([]string{
+		"type:oneof",
+		"area_id[1]:areaid",
+		"date[1]:date",
+		"version:versions",
+		"regexp:re2",
+		"count:oneof",
+		"filter:filterexpr",
+		"where:where",
+		"date:complete_date",
+	}, v.rules(out))
+}
+
+func (v *ValidationHandlersSuite) TestLimits() {
+	areas := "A1"
+	for i := 0; i < 50; i++ {
+		areas += ",A1"
+	}
+	longRegexp := make([]byte, 257)
+	for i := range longRegexp {
+		longRegexp[i] = 'a'
+	}
+	code, out := v.get(url.Values{"type": {"w"}, "area_id": {areas}, "date": {"2023-10-10 00:00:00"}, "regexp": {string(longRegexp)}})
+	v.Equal(http.StatusBadRequest, code)
+	v.ElementsMatch([]string{"area_id:max", "regexp:max"}, v.rules(out))
+}
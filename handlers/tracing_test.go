@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"bigtable_api/handlers"
+	"bigtable_api/router"
+	"bigtable_api/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type TracingHandlersSuite struct {
+	suite.Suite
+	exporter *tracetest.InMemoryExporter
+}
+
+func TestTracingHandlersSuite(t *testing.T) {
+	suite.Run(t, new(TracingHandlersSuite))
+}
+
+func (s *TracingHandlersSuite) SetupTest() {
+	s.exporter = tracetest.NewInMemoryExporter()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	s.T().Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+func (s *TracingHandlersSuite) TestSpans() {
+	climateHandler := handlers.NewClimateHandler(usecase.NewClimateUsecase(stubGateway{}))
+	r := router.InitializeRouter(climateHandler, nil, handlers.NewHealthHandler())
+
+	req, err := http.NewRequest(http.MethodGet, "/read/climate-data?type=w&area_id=A327734", nil)
+	s.Nil(err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	spans := s.exporter.GetSpans()
+	names := make(map[string]string)
+	for _, span := range spans {
+		s.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+		names[span.Name] = span.Parent.SpanID().String()
+	}
+	s.Contains(names, "/read/climate-data")
+	s.Contains(names, "ClimateUsecase.ReadPrefix")
+	s.Contains(names, "encode response")
+	s.Equal("00f067aa0ba902b7", names["/read/climate-data"])
+}
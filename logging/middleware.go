@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID reuses the X-Request-ID of the request, or generates one, and
+// puts a logger tagged with it in the request context, so that the handler,
+// usecase and repository logs of a request can be correlated. It also logs
+// every request once it is served.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		ctx.Header(RequestIDHeader, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.IsValid() {
+			logger = logger.With("trace_id", spanContext.TraceID().String(), "span_id", spanContext.SpanID().String())
+		}
+		ctx.Request = ctx.Request.WithContext(WithLogger(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		level := slog.LevelInfo
+		if ctx.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx.Request.Context(), level, "request served",
+			slog.Group("httpRequest",
+				slog.String("requestMethod", ctx.Request.Method),
+				slog.String("requestUrl", ctx.Request.URL.RequestURI()),
+				slog.Int("status", ctx.Writer.Status()),
+				slog.Int("responseSize", ctx.Writer.Size()),
+				slog.String("remoteIp", ctx.ClientIP()),
+				slog.String("userAgent", ctx.Request.UserAgent()),
+				slog.String("latency", fmt.Sprintf("%.9fs", time.Since(start).Seconds())),
+			),
+		)
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,85 @@
+package logging_test
+
+import (
+	"bigtable_api/logging"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type RequestIDSuite struct {
+	suite.Suite
+	logs   *bytes.Buffer
+	router *gin.Engine
+}
+
+func TestRequestIDSuite(t *testing.T) {
+	suite.Run(t, new(RequestIDSuite))
+}
+
+func (r *RequestIDSuite) SetupTest() {
+	previous := slog.Default()
+	r.T().Cleanup(func() { slog.SetDefault(previous) })
+
+	r.logs = &bytes.Buffer{}
+	logging.Init(r.logs)
+
+	gin.SetMode(gin.TestMode)
+	r.router = gin.New()
+	r.router.ContextWithFallback = true
+	r.router.Use(logging.RequestID())
+	r.router.GET("/", func(ctx *gin.Context) {
+		logging.FromContext(ctx).Info("handled")
+		ctx.Status(http.StatusOK)
+	})
+}
+
+func (r *RequestIDSuite) serve(requestID string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	r.Nil(err)
+	if requestID != "" {
+		req.Header.Set(logging.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.router.ServeHTTP(w, req)
+	return w
+}
+
+func (r *RequestIDSuite) entries() []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(r.logs.String()), "\n") {
+		var entry map[string]interface{}
+		r.Nil(json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (r *RequestIDSuite) TestPropagatesRequestID() {
+	w := r.serve("abc-123")
+	r.Equal("abc-123", w.Header().Get(logging.RequestIDHeader))
+
+	entries := r.entries()
+	r.Len(entries, 2)
+	for _, entry := range entries {
+		r.Equal("abc-123", entry["request_id"])
+		r.Equal("INFO", entry["severity"])
+	}
+	r.Equal("handled", entries[0]["message"])
+	r.Equal("request served", entries[1]["message"])
+}
+
+func (r *RequestIDSuite) TestGeneratesRequestID() {
+	w := r.serve("")
+	r.Len(w.Header().Get(logging.RequestIDHeader), 32)
+
+	w = r.serve("invalid id with spaces")
+	r.NotEqual("invalid id with spaces", w.Header().Get(logging.RequestIDHeader))
+}
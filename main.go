@@ -1,33 +1,160 @@
 package main
 
 import (
+	"bigtable_api/auth"
 	"bigtable_api/database"
+	"bigtable_api/entity"
 	"bigtable_api/handlers"
+	"bigtable_api/logging"
+	"bigtable_api/ratelimit"
 	"bigtable_api/repository"
 	"bigtable_api/router"
 	"bigtable_api/server"
+	"bigtable_api/stations"
+	"bigtable_api/tracing"
 	"bigtable_api/usecase"
 	"context"
-	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
-const port = "7000"
+const (
+	port     = "7000"
+	grpcPort = "7001"
+)
 
 func main() {
+	logging.Init(os.Stdout)
+	if keyTimezone := os.Getenv("KEY_TIMEZONE"); keyTimezone != "" {
+		loc, err := time.LoadLocation(keyTimezone)
+		if err != nil {
+			fatal("error loading KEY_TIMEZONE", err)
+		}
+		entity.KeyLocation = loc
+	}
+
 	ctx := context.Background()
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		fatal("error initializing tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	clientInstance, err := database.GetClientSingleton(ctx)
 	if err != nil {
-		log.Fatalln("error creating database instance. Error: ", err.Error())
+		fatal("error creating database instance", err)
 	}
 
 	climateRepo := repository.NewClimateRepository(clientInstance)
+	if family := os.Getenv("BIGTABLE_COLUMN_FAMILY"); family != "" {
+		climateRepo.Family = family
+	}
+	if column := os.Getenv("BIGTABLE_COLUMN"); column != "" {
+		climateRepo.Column = column
+	}
 
 	climateUsecase := usecase.NewClimateUsecase(climateRepo)
 
 	climateHandler := handlers.NewClimateHandler(climateUsecase)
 
-	router := router.InitializeRouter(climateHandler)
+	var catalog *stations.Catalog
+	if stationsFile := os.Getenv("STATIONS_FILE"); stationsFile != "" {
+		catalog, err = stations.LoadCatalog(stationsFile)
+		if err != nil {
+			fatal("error loading stations", err)
+		}
+	}
+	climateHandler.Stations = catalog
+	edrHandler := handlers.NewEDRHandler(climateUsecase, catalog)
+
+	healthChecks := []handlers.HealthCheck{{
+		Name:  "bigtable",
+		Probe: func(ctx context.Context) error { return climateRepo.Probe(ctx, "climate_data") },
+	}}
+	if os.Getenv("HEALTH_ADMIN_CHECK") == "true" {
+		adminInstance, err := database.GetAdminClientSingleton(ctx)
+		if err != nil {
+			fatal("error creating database admin instance", err)
+		}
+		healthChecks = append(healthChecks, handlers.HealthCheck{Name: "bigtable_admin", Probe: repository.AdminProbe(adminInstance, "climate_data")})
+	}
+	healthHandler := handlers.NewHealthHandler(healthChecks...)
+
+	var middlewares []gin.HandlerFunc
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
+	keyStore, tokenVerifier := initializeAuth(ctx)
+	if keyStore != nil || tokenVerifier != nil {
+		middlewares = append(middlewares, auth.Middleware(keyStore, tokenVerifier))
+		unaryInterceptors = append(unaryInterceptors, auth.UnaryInterceptor(keyStore, tokenVerifier))
+		streamInterceptors = append(streamInterceptors, auth.StreamInterceptor(keyStore, tokenVerifier))
+	} else if os.Getenv("AUTH_DISABLED") != "true" {
+		fatal("no authentication configured. Set API_KEYS_FILE, JWKS_URL or AUTH_DISABLED=true", nil)
+	} else {
+		slog.Warn("Authentication disabled")
+	}
+
+	if rateLimitsFile := os.Getenv("RATE_LIMITS_FILE"); rateLimitsFile != "" {
+		config, err := ratelimit.LoadConfig(rateLimitsFile)
+		if err != nil {
+			fatal("error loading rate limits", err)
+		}
+		middlewares = append(middlewares, ratelimit.Middleware(ratelimit.NewLimiter(config)))
+	}
+
+	grpcServer := router.InitializeGRPC(handlers.NewClimateGRPCServer(climateUsecase), unaryInterceptors, streamInterceptors)
+	router := router.InitializeRouter(climateHandler, edrHandler, healthHandler, middlewares...)
 
 	server := server.NewServer(":"+port, router)
+	grpcAddr := ":" + grpcPort
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		grpcAddr = ":" + port
+	}
+	server.ServeGRPC(grpcAddr, grpcServer)
+	server.OnShutdown(healthHandler.Drain)
+	if drainDelay := os.Getenv("SHUTDOWN_DRAIN_DELAY"); drainDelay != "" {
+		server.DrainDelay, err = time.ParseDuration(drainDelay)
+		if err != nil {
+			fatal("error parsing SHUTDOWN_DRAIN_DELAY", err)
+		}
+	}
 	server.Start()
 }
+
+func initializeAuth(ctx context.Context) (*auth.KeyStore, *auth.TokenVerifier) {
+	var keyStore *auth.KeyStore
+	if keysFile := os.Getenv("API_KEYS_FILE"); keysFile != "" {
+		var err error
+		keyStore, err = auth.NewKeyStore(keysFile)
+		if err != nil {
+			fatal("error loading api keys", err)
+		}
+	}
+
+	var tokenVerifier *auth.TokenVerifier
+	if jwksURL := os.Getenv("JWKS_URL"); jwksURL != "" {
+		jwks, err := auth.NewJWKS(ctx, jwksURL)
+		if err != nil {
+			fatal("error loading jwks", err)
+		}
+		tokenVerifier, err = auth.NewTokenVerifier(jwks, auth.TokenConfig{
+			Issuer:      os.Getenv("JWT_ISSUER"),
+			Audience:    os.Getenv("JWT_AUDIENCE"),
+			TenantClaim: os.Getenv("JWT_TENANT_CLAIM"),
+			TenantsFile: os.Getenv("TENANTS_FILE"),
+		})
+		if err != nil {
+			fatal("error loading tenants", err)
+		}
+	}
+	return keyStore, tokenVerifier
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+package meteo
+
+import "math"
+
+// Methods of the growing degree days.
+const (
+	// GDDAverage subtracts the base from the mean of the minimum and the
+	// maximum temperatures, both capped.
+	GDDAverage = "average"
+	// GDDTriangle takes the temperature as rising linearly from the minimum to
+	// the maximum and back, and integrates it between the base and the cap.
+	GDDTriangle = "triangle"
+)
+
+// GrowingDegreeDays returns the degree days, in degC day, of a day with the
+// minimum and maximum temperatures tmin and tmax, for a crop that develops
+// from the base temperature up to the cap.
+func GrowingDegreeDays(tmin, tmax, base, limit float64, method string) float64 {
+	if method != GDDTriangle || tmax <= tmin {
+		mean := (math.Min(tmin, limit) + math.Min(tmax, limit)) / 2
+		return math.Max(mean-base, 0)
+	}
+	switch {
+	case tmax <= base:
+		return 0
+	case tmin >= limit:
+		return limit - base
+	}
+	// the fraction of the day above a temperature times its mean excess
+	above := func(t float64) float64 {
+		if tmax <= t {
+			return 0
+		}
+		if tmin >= t {
+			return (tmin+tmax)/2 - t
+		}
+		return (tmax - t) * (tmax - t) / (2 * (tmax - tmin))
+	}
+	return above(base) - above(limit)
+}
+
+// ChillHour reports whether an hour at temperature t counts as a chill hour,
+// between 0 and 7.2 degC (45 degF).
+func ChillHour(t float64) bool {
+	return t >= 0 && t <= 7.2
+}
+
+// UtahChillUnits returns the chill units of an hour at temperature t by the
+// Utah model of Richardson et al. (1974), where warm hours undo the chill.
+func UtahChillUnits(t float64) float64 {
+	switch {
+	case t < 1.5:
+		return 0
+	case t < 2.5:
+		return 0.5
+	case t < 9.2:
+		return 1
+	case t < 12.5:
+		return 0.5
+	case t < 16:
+		return 0
+	case t <= 18:
+		return -0.5
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,51 @@
+package meteo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowingDegreeDays(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		tmin, tmax float64
+		method     string
+		expected   float64
+	}{
+		{"average", 14, 26, GDDAverage, 10},
+		{"average of a cold day", 2, 12, GDDAverage, 0},
+		{"average capped", 20, 36, GDDAverage, 15},
+		{"triangle between base and cap", 14, 26, GDDTriangle, 10},
+		// above the base for half of the day, 4 degrees above on average
+		{"triangle below base", 2, 18, GDDTriangle, 2},
+		// the mean excess of 18 less 6/16 of the day above the cap, 3 degrees above on average
+		{"triangle above cap", 20, 36, GDDTriangle, 18 - 1.125},
+		{"triangle across base and cap", 0, 40, GDDTriangle, 10},
+		{"triangle of a cold day", 2, 8, GDDTriangle, 0},
+		{"triangle of a hot day", 32, 38, GDDTriangle, 20},
+		{"triangle of a constant day", 15, 15, GDDTriangle, 5},
+	} {
+		assert.InDelta(t, test.expected, GrowingDegreeDays(test.tmin, test.tmax, 10, 30, test.method), 1e-9, test.name)
+	}
+}
+
+func TestChill(t *testing.T) {
+	for _, test := range []struct {
+		t     float64
+		hour  bool
+		units float64
+	}{
+		{-2, false, 0},
+		{0, true, 0},
+		{2, true, 0.5},
+		{7.2, true, 1},
+		{9.2, false, 0.5},
+		{14, false, 0},
+		{17, false, -0.5},
+		{25, false, -1},
+	} {
+		assert.Equal(t, test.hour, ChillHour(test.t), "chill hour at %v", test.t)
+		assert.Equal(t, test.units, UtahChillUnits(test.t), "Utah units at %v", test.t)
+	}
+}
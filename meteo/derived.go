@@ -0,0 +1,84 @@
+package meteo
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// DerivedVariables are the variables that can be added to the payloads.
+var DerivedVariables = []string{"dewPoint", "vpd", "heatIndex", "windChill"}
+
+// Derive computes a derived variable from the variables of a payload, and
+// returns false when it is unknown or they lack one of its inputs.
+func Derive(name string, variables map[string]float64) (float64, bool) {
+	t, hasT := variables["temperatureInst"]
+	rh, hasRH := variables["humidityInst"]
+	// the logarithm of the dew point is undefined for dry air
+	hasRH = hasRH && rh > 0 && rh <= 100
+	switch name {
+	case "dewPoint":
+		if hasT && hasRH {
+			return DewPoint(t, rh), true
+		}
+	case "vpd":
+		if hasT && hasRH {
+			return VaporPressureDeficit(t, rh), true
+		}
+	case "heatIndex":
+		if hasT && hasRH {
+			return HeatIndex(t, rh), true
+		}
+	case "windChill":
+		if v, hasV := variables["windSpeedInst"]; hasT && hasV && v >= 0 {
+			return WindChill(t, v), true
+		}
+	}
+	return 0, false
+}
+
+// AddDerived adds the derived variables, rounded to hundredths, to the data
+// objects of a payload that hold their inputs. The payload is returned as it
+// is when none of them can be computed.
+func AddDerived(value string, names []string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", err
+	}
+	changed := false
+	for field, raw := range fields {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &data); err != nil {
+			continue
+		}
+		variables := make(map[string]float64, len(data))
+		for name, raw := range data {
+			var number float64
+			if err := json.Unmarshal(raw, &number); err == nil {
+				variables[name] = number
+			}
+		}
+		added := false
+		for _, name := range names {
+			if derived, ok := Derive(name, variables); ok {
+				data[name], _ = json.Marshal(math.Round(derived*100) / 100)
+				added = true
+			}
+		}
+		if !added {
+			continue
+		}
+		var err error
+		if fields[field], err = json.Marshal(data); err != nil {
+			return "", err
+		}
+		changed = true
+	}
+	if !changed {
+		return value, nil
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,95 @@
+package meteo
+
+import (
+	"math"
+	"time"
+)
+
+// solarConstant is in MJ m-2 min-1, and stefanBoltzmann in MJ K-4 m-2 day-1.
+const (
+	solarConstant   = 0.0820
+	stefanBoltzmann = 4.903e-9
+)
+
+// DailyWeather holds the daily inputs of the reference evapotranspiration.
+type DailyWeather struct {
+	// Date is the day, for the position of the sun.
+	Date time.Time
+	// Latitude is in degrees, negative in the southern hemisphere, and
+	// Elevation in meters above sea level.
+	Latitude, Elevation float64
+	// TMin and TMax are the extremes of the temperature, in degC, and RHMin
+	// and RHMax those of the relative humidity, in %.
+	TMin, TMax   float64
+	RHMin, RHMax float64
+	// WindSpeed is the mean wind speed at 2 m, in m s-1.
+	WindSpeed float64
+	// SolarRadiation is the radiation of the day, in MJ m-2.
+	SolarRadiation float64
+}
+
+// ReferenceEvapotranspiration returns the evapotranspiration, in mm, of the
+// reference grass over a day, by the Penman-Monteith equation 6 of FAO-56
+// with the soil heat flux of a day taken as zero.
+func ReferenceEvapotranspiration(d DailyWeather) float64 {
+	t := (d.TMin + d.TMax) / 2
+	gamma := 0.000665 * AtmosphericPressure(d.Elevation)
+	delta := 4098 * SaturationVaporPressure(t) / ((t + 237.3) * (t + 237.3))
+
+	saturation := (SaturationVaporPressure(d.TMin) + SaturationVaporPressure(d.TMax)) / 2
+	actual := (SaturationVaporPressure(d.TMin)*d.RHMax/100 + SaturationVaporPressure(d.TMax)*d.RHMin/100) / 2
+
+	ra := ExtraterrestrialRadiation(d.Latitude, d.Date.YearDay())
+	clearSky := (0.75 + 2e-5*d.Elevation) * ra
+	netShortwave := (1 - 0.23) * d.SolarRadiation
+	relative := 1.0
+	if clearSky > 0 {
+		relative = math.Min(d.SolarRadiation/clearSky, 1)
+	}
+	netLongwave := stefanBoltzmann * (math.Pow(d.TMax+273.16, 4) + math.Pow(d.TMin+273.16, 4)) / 2 *
+		(0.34 - 0.14*math.Sqrt(actual)) * (1.35*relative - 0.35)
+	net := netShortwave - netLongwave
+
+	et0 := (0.408*delta*net + gamma*900/(t+273)*d.WindSpeed*(saturation-actual)) /
+		(delta + gamma*(1+0.34*d.WindSpeed))
+	return math.Max(et0, 0)
+}
+
+// AtmosphericPressure returns the pressure, in kPa, at an elevation in meters.
+func AtmosphericPressure(elevation float64) float64 {
+	return 101.3 * math.Pow((293-0.0065*elevation)/293, 5.26)
+}
+
+// Elevation returns the elevation, in meters, of an atmospheric pressure in
+// kPa, the inverse of AtmosphericPressure.
+func Elevation(pressure float64) float64 {
+	return 293 / 0.0065 * (1 - math.Pow(pressure/101.3, 1/5.26))
+}
+
+// ExtraterrestrialRadiation returns the radiation, in MJ m-2, reaching the top
+// of the atmosphere over a day of the year at a latitude in degrees.
+func ExtraterrestrialRadiation(latitude float64, dayOfYear int) float64 {
+	phi := latitude * math.Pi / 180
+	j := float64(dayOfYear)
+	distance := 1 + 0.033*math.Cos(2*math.Pi/365*j)
+	declination := 0.409 * math.Sin(2*math.Pi/365*j-1.39)
+	// the polar days and nights bound the sunset hour angle
+	sunset := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(declination))))
+	return 24 * 60 / math.Pi * solarConstant * distance *
+		(sunset*math.Sin(phi)*math.Sin(declination) + math.Cos(phi)*math.Cos(declination)*math.Sin(sunset))
+}
+
+// WindSpeedAt2m converts a wind speed measured at a height in meters to the
+// speed at 2 m, by the logarithmic profile of FAO-56.
+func WindSpeedAt2m(speed, height float64) float64 {
+	return speed * 4.87 / math.Log(67.8*height-5.42)
+}
+
+// WaterBalance moves the soil water storage, in mm, of a day with rain and
+// evapotranspiration, returning the new storage, between 0 and the capacity,
+// and the drainage of the water above the capacity.
+func WaterBalance(storage, capacity, rain, et0 float64) (float64, float64) {
+	storage += rain - et0
+	drainage := math.Max(storage-capacity, 0)
+	return math.Min(math.Max(storage, 0), capacity), drainage
+}
@@ -0,0 +1,50 @@
+package meteo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceEvapotranspiration(t *testing.T) {
+	// example 18 of FAO-56, Brussels on the 6th of July
+	brussels := DailyWeather{
+		Date:           time.Date(2023, 7, 6, 0, 0, 0, 0, time.UTC),
+		Latitude:       50 + 48.0/60,
+		Elevation:      100,
+		TMin:           12.3,
+		TMax:           21.5,
+		RHMin:          63,
+		RHMax:          84,
+		WindSpeed:      WindSpeedAt2m(10/3.6, 10),
+		SolarRadiation: 22.07,
+	}
+	assert.InDelta(t, 2.078, brussels.WindSpeed, 0.001)
+	assert.InDelta(t, 41.09, ExtraterrestrialRadiation(brussels.Latitude, 187), 0.01)
+	assert.InDelta(t, 3.9, ReferenceEvapotranspiration(brussels), 0.05)
+
+	// example 8 of FAO-56, the 3rd of September at 20 degrees south
+	assert.InDelta(t, 32.2, ExtraterrestrialRadiation(-20, 246), 0.05)
+	// no sunshine in the polar night
+	assert.Equal(t, 0.0, ExtraterrestrialRadiation(80, 355))
+}
+
+func TestAtmosphericPressure(t *testing.T) {
+	// example 2 of FAO-56
+	assert.InDelta(t, 81.8, AtmosphericPressure(1800), 0.05)
+	assert.InDelta(t, 1800, Elevation(AtmosphericPressure(1800)), 1e-6)
+}
+
+func TestWaterBalance(t *testing.T) {
+	storage, drainage := WaterBalance(80, 100, 30, 4)
+	assert.Equal(t, 100.0, storage)
+	assert.Equal(t, 6.0, drainage)
+
+	storage, drainage = WaterBalance(3, 100, 0, 5)
+	assert.Equal(t, 0.0, storage)
+	assert.Equal(t, 0.0, drainage)
+
+	storage, _ = WaterBalance(50, 100, 2, 5)
+	assert.Equal(t, 47.0, storage)
+}
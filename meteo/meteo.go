@@ -0,0 +1,79 @@
+// Package meteo computes meteorological variables from the ones measured by
+// the stations, so that every client gets them from the same formulas:
+//
+//   - the dew point, with the Magnus formula and the coefficients of
+//     Alduchov and Eskridge (1996)
+//   - the vapour pressure deficit, with the saturation vapour pressure of
+//     FAO-56, in kPa
+//   - the heat index, with the regression of Rothfusz (1990) and the
+//     adjustments of the US National Weather Service
+//   - the wind chill, with the formula of Environment Canada and the National
+//     Weather Service (2001)
+//
+// It also computes the agronomic indices: the growing degree days, the chill
+// hours and units, the reference evapotranspiration of FAO-56 and a soil
+// water balance.
+//
+// Temperatures are in degC, relative humidity in % and wind speed in m s-1,
+// as in the payloads.
+package meteo
+
+import "math"
+
+// Magnus coefficients over water, for temperatures in degC.
+const (
+	magnusA = 17.625
+	magnusB = 243.04
+)
+
+// DewPoint returns the temperature, in degC, at which the air at temperature
+// t and relative humidity rh becomes saturated.
+func DewPoint(t, rh float64) float64 {
+	gamma := math.Log(rh/100) + magnusA*t/(magnusB+t)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// SaturationVaporPressure returns the saturation vapour pressure, in kPa, of
+// the air at temperature t, as equation 11 of FAO-56.
+func SaturationVaporPressure(t float64) float64 {
+	return 0.6108 * math.Exp(17.27*t/(t+237.3))
+}
+
+// VaporPressureDeficit returns the difference, in kPa, between the saturation
+// and the actual vapour pressures of the air at temperature t and relative
+// humidity rh.
+func VaporPressureDeficit(t, rh float64) float64 {
+	return SaturationVaporPressure(t) * (1 - rh/100)
+}
+
+// HeatIndex returns the temperature, in degC, felt in the shade at
+// temperature t and relative humidity rh. Below about 27 degC it is close to
+// the temperature, by the simple formula of Steadman.
+func HeatIndex(t, rh float64) float64 {
+	f := t*9/5 + 32
+	index := 0.5 * (f + 61 + (f-68)*1.2 + rh*0.094)
+	if (index+f)/2 >= 80 {
+		index = -42.379 + 2.04901523*f + 10.14333127*rh -
+			0.22475541*f*rh - 0.00683783*f*f - 0.05481717*rh*rh +
+			0.00122874*f*f*rh + 0.00085282*f*rh*rh - 0.00000199*f*f*rh*rh
+		switch {
+		case rh < 13 && f >= 80 && f <= 112:
+			index -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(f-95))/17)
+		case rh > 85 && f >= 80 && f <= 87:
+			index += (rh - 85) / 10 * (87 - f) / 5
+		}
+	}
+	return (index - 32) * 5 / 9
+}
+
+// WindChill returns the temperature, in degC, felt on the skin at
+// temperature t with wind speed v. The formula is defined up to 10 degC and
+// from 4.8 km/h, outside of which the wind chill is the temperature.
+func WindChill(t, v float64) float64 {
+	kmh := v * 3.6
+	if t > 10 || kmh <= 4.8 {
+		return t
+	}
+	factor := math.Pow(kmh, 0.16)
+	return 13.12 + 0.6215*t - 11.37*factor + 0.3965*t*factor
+}
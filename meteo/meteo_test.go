@@ -0,0 +1,66 @@
+package meteo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormulas(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"dew point at 20 degC and 50%", DewPoint(20, 50), 9.26},
+		{"dew point of saturated air", DewPoint(15, 100), 15},
+		{"saturation vapour pressure at 20 degC", SaturationVaporPressure(20), 2.338},
+		{"vapour pressure deficit at 25 degC and 50%", VaporPressureDeficit(25, 50), 1.584},
+		{"vapour pressure deficit of saturated air", VaporPressureDeficit(25, 100), 0},
+		// 90 degF and 70% is 106 degF in the table of the National Weather Service
+		{"heat index at 32.2 degC and 70%", HeatIndex(32.2222, 70), 41.1},
+		{"heat index of dry hot air", HeatIndex(43.3333, 10), 40.2},
+		// 82.4 degF and 90% is 93 degF, with the adjustment for humid air
+		{"heat index of humid warm air", HeatIndex(28, 90), 34.0},
+		{"heat index of mild air", HeatIndex(20, 50), 19.4},
+		// -10 degC and 20 km/h is -18 in the table of Environment Canada
+		{"wind chill at -10 degC and 20 km/h", WindChill(-10, 20/3.6), -17.9},
+		{"wind chill of calm air", WindChill(-10, 1), -10},
+		{"wind chill of warm air", WindChill(15, 10), 15},
+	} {
+		assert.InDelta(t, test.expected, test.value, 0.05, test.name)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	variables := map[string]float64{"temperatureInst": 25, "humidityInst": 50, "windSpeedInst": 3}
+	for _, name := range DerivedVariables {
+		_, ok := Derive(name, variables)
+		assert.True(t, ok, name)
+	}
+
+	_, ok := Derive("dewPoint", map[string]float64{"temperatureInst": 25})
+	assert.False(t, ok)
+	_, ok = Derive("dewPoint", map[string]float64{"temperatureInst": 25, "humidityInst": 0})
+	assert.False(t, ok)
+	_, ok = Derive("windChill", map[string]float64{"temperatureInst": 25, "humidityInst": 50})
+	assert.False(t, ok)
+	_, ok = Derive("feelsLike", variables)
+	assert.False(t, ok)
+}
+
+func TestAddDerived(t *testing.T) {
+	value, err := AddDerived(`{"lonlat":[-50.6,-17.7],"weatherData":{"temperatureInst":25,"humidityInst":50}}`, []string{"dewPoint", "vpd", "windChill"})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"lonlat":[-50.6,-17.7],"weatherData":{"temperatureInst":25,"humidityInst":50,"dewPoint":13.86,"vpd":1.58}}`, value)
+	}
+
+	// payloads without the inputs are left as they are
+	payload := `{"lonlat":[-50.6,-17.7],"weatherData":{"rain":0}}`
+	value, err = AddDerived(payload, DerivedVariables)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, value)
+
+	_, err = AddDerived(`not json`, DerivedVariables)
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route, method, status and datatype.",
+	}, []string{"route", "method", "status", "datatype"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route, status and datatype.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"route", "status", "datatype"})
+
+	bigtableDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bigtable_read_duration_seconds",
+		Help:    "Bigtable read latency, by table and row set type.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"table", "row_set"})
+
+	bigtableRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bigtable_rows_returned_total",
+		Help: "Rows returned by Bigtable reads.",
+	}, []string{"table", "row_set"})
+
+	bigtableCells = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bigtable_cells_returned_total",
+		Help: "Cells returned by Bigtable reads.",
+	}, []string{"table", "row_set"})
+
+	bigtableBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bigtable_bytes_scanned_total",
+		Help: "Bytes of row keys and cell values returned by Bigtable reads.",
+	}, []string{"table", "row_set"})
+
+	bigtableErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bigtable_read_errors_total",
+		Help: "Failed Bigtable reads, by gRPC code.",
+	}, []string{"table", "row_set", "code"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware records the count and latency of the requests. Routes are
+// labelled by their template, so that IDs in paths do not become labels.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		statusCode := strconv.Itoa(ctx.Writer.Status())
+		dataType := datatypeLabel(ctx.Query("type"))
+		httpRequests.WithLabelValues(route, ctx.Request.Method, statusCode, dataType).Inc()
+		httpDuration.WithLabelValues(route, statusCode, dataType).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ReadStats accumulates what a Bigtable read returned.
+type ReadStats struct {
+	Rows  int
+	Cells int
+	Bytes int
+}
+
+// ObserveRead records a Bigtable read that started at start.
+func ObserveRead(table, rowSet string, start time.Time, stats ReadStats, err error) {
+	bigtableDuration.WithLabelValues(table, rowSet).Observe(time.Since(start).Seconds())
+	bigtableRows.WithLabelValues(table, rowSet).Add(float64(stats.Rows))
+	bigtableCells.WithLabelValues(table, rowSet).Add(float64(stats.Cells))
+	bigtableBytes.WithLabelValues(table, rowSet).Add(float64(stats.Bytes))
+	if err != nil {
+		code := status.Code(err)
+		if code == codes.Unknown {
+			// context cancellations and deadlines are not grpc errors
+			code = status.FromContextError(err).Code()
+		}
+		bigtableErrors.WithLabelValues(table, rowSet, code.String()).Inc()
+	}
+}
+
+// datatypeLabel keeps the label cardinality bounded whatever the clients send.
+func datatypeLabel(dataType string) string {
+	switch dataType {
+	case "w", "f", "":
+		return dataType
+	default:
+		return "other"
+	}
+}
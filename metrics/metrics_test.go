@@ -0,0 +1,59 @@
+package metrics_test
+
+import (
+	"bigtable_api/metrics"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
+
+func (m *MetricsSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	m.router = gin.New()
+	m.router.Use(metrics.Middleware())
+	m.router.GET("/metrics", metrics.Handler())
+	m.router.GET("/read/climate-data", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+}
+
+func (m *MetricsSuite) get(url string) string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	m.Nil(err)
+	w := httptest.NewRecorder()
+	m.router.ServeHTTP(w, req)
+	body, err := io.ReadAll(w.Body)
+	m.Nil(err)
+	return string(body)
+}
+
+func (m *MetricsSuite) TestExposition() {
+	m.get("/read/climate-data?type=w&area_id=A327734")
+	m.get("/read/climate-data?type=something-else")
+	metrics.ObserveRead("climate_data", "prefix", time.Now(), metrics.ReadStats{Rows: 2, Cells: 3, Bytes: 100}, nil)
+	metrics.ObserveRead("climate_data", "range", time.Now(), metrics.ReadStats{}, status.Error(codes.Unavailable, "unavailable"))
+	metrics.ObserveRead("climate_data", "range", time.Now(), metrics.ReadStats{}, context.DeadlineExceeded)
+
+	body := m.get("/metrics")
+	m.Contains(body, `http_requests_total{datatype="w",method="GET",route="/read/climate-data",status="200"} 1`)
+	m.Contains(body, `http_requests_total{datatype="other",method="GET",route="/read/climate-data",status="200"} 1`)
+	m.Contains(body, `bigtable_rows_returned_total{row_set="prefix",table="climate_data"} 2`)
+	m.Contains(body, `bigtable_cells_returned_total{row_set="prefix",table="climate_data"} 3`)
+	m.Contains(body, `bigtable_read_errors_total{code="Unavailable",row_set="range",table="climate_data"} 1`)
+	m.Contains(body, `bigtable_read_errors_total{code="DeadlineExceeded",row_set="range",table="climate_data"} 1`)
+}
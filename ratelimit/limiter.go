@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// idleTimeout is how long a client can stay silent before its state is dropped.
+const idleTimeout = 24 * time.Hour
+
+// Tier configures the limits of a group of clients.
+type Tier struct {
+	// RequestsPerSecond is the refill rate of the token bucket. Zero means no
+	// limit on requests.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is the bucket capacity.
+	Burst int `json:"burst"`
+	// DailyRows caps the rows returned to the client per UTC day. Zero means no quota.
+	DailyRows int `json:"daily_rows"`
+}
+
+type Config struct {
+	Default Tier            `json:"default"`
+	Tiers   map[string]Tier `json:"tiers"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	return config, err
+}
+
+func (c Config) tier(name string) Tier {
+	if tier, ok := c.Tiers[name]; ok {
+		return tier
+	}
+	return c.Default
+}
+
+type client struct {
+	tokens   float64
+	updated  time.Time
+	day      time.Time
+	rowsUsed int
+}
+
+// Decision is the outcome of a request against the limits of a client.
+type Decision struct {
+	Allowed bool
+	// Limit, Remaining and Reset describe the token bucket, and are zero when
+	// the requests are not limited.
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is set when the request is refused, in whole seconds.
+	RetryAfter time.Duration
+	QuotaLimit int
+	QuotaUsed  int
+}
+
+// Limiter keeps a token bucket and a daily row quota per client, in memory.
+// Each API instance enforces its limits independently.
+type Limiter struct {
+	config  Config
+	mu      sync.Mutex
+	clients map[string]*client
+	now     func() time.Time
+	swept   time.Time
+}
+
+func NewLimiter(config Config) *Limiter {
+	return &Limiter{config: config, clients: make(map[string]*client), now: time.Now}
+}
+
+// Allow takes a token from the bucket of the client, unless the bucket is
+// empty or the daily quota is exhausted.
+func (l *Limiter) Allow(clientID, tierName string) Decision {
+	tier := l.config.tier(tierName)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	c := l.client(clientID, tier, now)
+	c.tokens = math.Min(float64(tier.Burst), c.tokens+now.Sub(c.updated).Seconds()*tier.RequestsPerSecond)
+	c.updated = now
+
+	decision := Decision{QuotaLimit: tier.DailyRows, QuotaUsed: c.rowsUsed}
+	switch {
+	case tier.DailyRows > 0 && c.rowsUsed >= tier.DailyRows:
+		decision.RetryAfter = seconds(c.day.AddDate(0, 0, 1).Sub(now).Seconds())
+	case tier.RequestsPerSecond <= 0:
+		decision.Allowed = true
+	case c.tokens < 1:
+		decision.RetryAfter = seconds((1 - c.tokens) / tier.RequestsPerSecond)
+	default:
+		c.tokens--
+		decision.Allowed = true
+	}
+	if tier.RequestsPerSecond > 0 {
+		decision.Limit = tier.Burst
+		decision.Remaining = int(c.tokens)
+		decision.Reset = seconds((float64(tier.Burst) - c.tokens) / tier.RequestsPerSecond)
+	}
+	return decision
+}
+
+// AddRows counts rows returned to the client against its daily quota.
+func (l *Limiter) AddRows(clientID string, rows int) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := l.clients[clientID]; ok {
+		l.resetDay(c, now)
+		c.rowsUsed += rows
+	}
+}
+
+func (l *Limiter) client(clientID string, tier Tier, now time.Time) *client {
+	c, ok := l.clients[clientID]
+	if !ok {
+		c = &client{tokens: float64(tier.Burst), updated: now}
+		l.clients[clientID] = c
+	}
+	l.resetDay(c, now)
+	return c
+}
+
+func (l *Limiter) resetDay(c *client, now time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !c.day.Equal(day) {
+		c.day = day
+		c.rowsUsed = 0
+	}
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Hour {
+		return
+	}
+	l.swept = now
+	for id, c := range l.clients {
+		if now.Sub(c.updated) > idleTimeout {
+			delete(l.clients, id)
+		}
+	}
+}
+
+func seconds(value float64) time.Duration {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0
+	}
+	return time.Duration(math.Ceil(value)) * time.Second
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LimiterSuite struct {
+	suite.Suite
+	now     time.Time
+	limiter *Limiter
+}
+
+func TestLimiterSuite(t *testing.T) {
+	suite.Run(t, new(LimiterSuite))
+}
+
+func (l *LimiterSuite) SetupTest() {
+	l.now = time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC)
+	l.limiter = NewLimiter(Config{
+		Default: Tier{RequestsPerSecond: 1, Burst: 2, DailyRows: 100},
+		Tiers:   map[string]Tier{"premium": {RequestsPerSecond: 10, Burst: 20}},
+	})
+	l.limiter.now = func() time.Time { return l.now }
+}
+
+func (l *LimiterSuite) TestTokenBucket() {
+	l.True(l.limiter.Allow("a", "").Allowed)
+	decision := l.limiter.Allow("a", "")
+	l.True(decision.Allowed)
+	l.Equal(0, decision.Remaining)
+
+	decision = l.limiter.Allow("a", "")
+	l.False(decision.Allowed)
+	l.Equal(time.Second, decision.RetryAfter)
+
+	// other clients have their own bucket
+	l.True(l.limiter.Allow("b", "").Allowed)
+
+	l.now = l.now.Add(time.Second)
+	l.True(l.limiter.Allow("a", "").Allowed)
+}
+
+func (l *LimiterSuite) TestTiers() {
+	for i := 0; i < 20; i++ {
+		l.True(l.limiter.Allow("a", "premium").Allowed)
+	}
+	l.False(l.limiter.Allow("a", "premium").Allowed)
+	// unknown tiers fall back to the default one
+	l.Equal(2, l.limiter.Allow("b", "unknown-tier").Limit)
+}
+
+func (l *LimiterSuite) TestDailyQuota() {
+	l.True(l.limiter.Allow("a", "").Allowed)
+	l.limiter.AddRows("a", 100)
+
+	l.now = l.now.Add(time.Minute)
+	decision := l.limiter.Allow("a", "")
+	l.False(decision.Allowed)
+	l.Equal(12*time.Hour-time.Minute, decision.RetryAfter)
+
+	l.now = time.Date(2023, 10, 11, 0, 0, 1, 0, time.UTC)
+	decision = l.limiter.Allow("a", "")
+	l.True(decision.Allowed)
+	l.Equal(0, decision.QuotaUsed)
+}
+
+func (l *LimiterSuite) TestQuotaRoundedUp() {
+	l.True(l.limiter.Allow("a", "").Allowed)
+	l.limiter.AddRows("a", 100)
+	l.now = time.Date(2023, 10, 10, 23, 59, 59, 500_000_000, time.UTC)
+	decision := l.limiter.Allow("a", "")
+	l.False(decision.Allowed)
+	l.Equal(time.Second, decision.RetryAfter)
+}
+
+func (l *LimiterSuite) TestQuotaOnly() {
+	l.limiter = NewLimiter(Config{Default: Tier{Burst: 5, DailyRows: 100}})
+	decision := l.limiter.Allow("a", "")
+	l.True(decision.Allowed)
+	// without requests per second there is no bucket to describe
+	l.Equal(0, decision.Limit)
+	l.Equal(time.Duration(0), decision.Reset)
+	l.Equal(100, decision.QuotaLimit)
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"bigtable_api/auth"
+	"bigtable_api/logging"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rowsKey   = "ratelimit_rows"
+	clientKey = "ratelimit_client"
+)
+
+// limitedClient is the client a request was admitted for.
+type limitedClient struct {
+	limiter  *Limiter
+	id, tier string
+}
+
+// Middleware limits requests per API key or token principal, or per client IP
+// for unauthenticated requests, and sets the RateLimit-* headers when the
+// requests are limited.
+func Middleware(limiter *Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientID, tier := "ip:"+ctx.ClientIP(), ""
+		if principal := auth.PrincipalFrom(ctx); principal != nil {
+			clientID, tier = "principal:"+principal.ID, principal.Tier
+		}
+		decision := limiter.Allow(clientID, tier)
+		header := ctx.Writer.Header()
+		if decision.Limit > 0 {
+			header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(wholeSeconds(decision.Reset)))
+		}
+		if decision.QuotaLimit > 0 {
+			header.Set("X-Quota-Limit", strconv.Itoa(decision.QuotaLimit))
+			header.Set("X-Quota-Used", strconv.Itoa(decision.QuotaUsed))
+		}
+
+		if !decision.Allowed {
+			header.Set("Retry-After", strconv.Itoa(wholeSeconds(decision.RetryAfter)))
+			logging.FromContext(ctx).Warn("rate limit exceeded", "client", clientID)
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"status": "failed",
+				"error":  fmt.Sprintf("rate limit exceeded, retry in %v", decision.RetryAfter),
+			})
+			return
+		}
+		ctx.Set(clientKey, limitedClient{limiter: limiter, id: clientID, tier: tier})
+		ctx.Next()
+
+		if rows := ctx.GetInt(rowsKey); rows > 0 {
+			limiter.AddRows(clientID, rows)
+		}
+	}
+}
+
+// wholeSeconds rounds a duration up to seconds, so that clients do not retry
+// before it is over.
+func wholeSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RecordRows reports the rows returned by the handler, which are counted
+// against the daily quota of the client once the request ends.
+func RecordRows(ctx *gin.Context, rows int) {
+	ctx.Set(rowsKey, ctx.GetInt(rowsKey)+rows)
+}
+
+// AllowQuery takes a token of the client for a query run by the request
+// besides the one it was admitted for, such as the queries of a batch, and
+// refuses it like a request when the bucket is empty or the daily quota is
+// exhausted. Queries are allowed when the requests are not limited.
+func AllowQuery(ctx *gin.Context) Decision {
+	value, ok := ctx.Get(clientKey)
+	if !ok {
+		return Decision{Allowed: true}
+	}
+	c := value.(limitedClient)
+	return c.limiter.Allow(c.id, c.tier)
+}
+
+// CountRows counts the rows against the daily quota of the client right away,
+// so that the next queries of the request are checked against them, unlike
+// RecordRows.
+func CountRows(ctx *gin.Context, rows int) {
+	if value, ok := ctx.Get(clientKey); ok {
+		c := value.(limitedClient)
+		c.limiter.AddRows(c.id, rows)
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type MiddlewareSuite struct {
+	suite.Suite
+}
+
+func TestMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(MiddlewareSuite))
+}
+
+func (m *MiddlewareSuite) serve(limiter *Limiter) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(limiter))
+	router.GET("/", func(ctx *gin.Context) {
+		RecordRows(ctx, 100)
+		ctx.Status(http.StatusOK)
+	})
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	m.Nil(err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (m *MiddlewareSuite) TestHeaders() {
+	limiter := NewLimiter(Config{Default: Tier{RequestsPerSecond: 1, Burst: 2}})
+	w := m.serve(limiter)
+	m.Equal(http.StatusOK, w.Code)
+	m.Equal("2", w.Header().Get("RateLimit-Limit"))
+	m.Equal("1", w.Header().Get("RateLimit-Remaining"))
+	m.Equal("1", w.Header().Get("RateLimit-Reset"))
+	m.Empty(w.Header().Get("X-Quota-Limit"))
+}
+
+func (m *MiddlewareSuite) TestQuotaOnly() {
+	now := time.Date(2023, 10, 10, 23, 59, 59, 500_000_000, time.UTC)
+	limiter := NewLimiter(Config{Default: Tier{DailyRows: 100}})
+	limiter.now = func() time.Time { return now }
+
+	w := m.serve(limiter)
+	m.Equal(http.StatusOK, w.Code)
+	for _, header := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"} {
+		m.NotContains(w.Header(), header)
+	}
+	m.Equal("100", w.Header().Get("X-Quota-Limit"))
+
+	// half a second before midnight the client is told to wait a whole one
+	w = m.serve(limiter)
+	m.Equal(http.StatusTooManyRequests, w.Code)
+	m.Equal("1", w.Header().Get("Retry-After"))
+}
+
+func (m *MiddlewareSuite) TestQueries() {
+	limiter := NewLimiter(Config{Default: Tier{DailyRows: 100}})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(limiter))
+	var decisions []Decision
+	router.GET("/", func(ctx *gin.Context) {
+		decisions = append(decisions, AllowQuery(ctx))
+		// the rows of a query are counted before the request ends
+		CountRows(ctx, 100)
+		decisions = append(decisions, AllowQuery(ctx))
+	})
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	m.Nil(err)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	m.Require().Len(decisions, 2)
+	m.True(decisions[0].Allowed)
+	m.False(decisions[1].Allowed)
+	m.Equal(100, decisions[1].QuotaUsed)
+
+	// without a limiter the queries are allowed
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	m.True(AllowQuery(ctx).Allowed)
+}
@@ -2,170 +2,328 @@ package repository
 
 import (
 	"bigtable_api/entity"
+	"bigtable_api/filterexpr"
+	"bigtable_api/logging"
+	"bigtable_api/metrics"
+	"bigtable_api/tracing"
 	"context"
 	"errors"
-	"log"
+	"fmt"
 	"strconv"
+	"time"
 
 	"cloud.google.com/go/bigtable"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultFamily and DefaultColumn hold the payload of the written cells.
+const (
+	DefaultFamily = "data"
+	DefaultColumn = "value"
 )
 
 type ClimateRepository struct {
 	ClientInstance *bigtable.Client
+	// Family and Column are where Write stores the cells. Reads return the
+	// cells of every column.
+	Family string
+	Column string
 }
 
 func NewClimateRepository(clientInstance *bigtable.Client) *ClimateRepository {
-	return &ClimateRepository{ClientInstance: clientInstance}
+	return &ClimateRepository{ClientInstance: clientInstance, Family: DefaultFamily, Column: DefaultColumn}
 }
 
 func (r *ClimateRepository) ReadPrefix(ctx context.Context, table, prefix string, filters map[string]string) ([]entity.BigtableOutput, error) {
-	log.Printf("Reading from table %s with prefix %s", table, prefix)
-
-	tbl := r.ClientInstance.Open(table)
-
-	var filterList []bigtable.Filter
-
-	if regexp, ok := filters["regexp"]; ok {
-		filterList = append(filterList, bigtable.RowKeyFilter(regexp))
+	var result []entity.BigtableOutput
+	err := r.StreamPrefix(ctx, table, prefix, filters, func(output entity.BigtableOutput) bool {
+		result = append(result, output)
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	if version, ok := filters["version"]; ok {
-		versionInt, err := strconv.Atoi(version)
-		if err != nil {
-			return nil, errors.New("wrong version filter")
-		}
-		filterList = append(filterList, bigtable.LatestNFilter(versionInt))
-	}
+// StreamPrefix reads the keys starting with prefix, calling fn for each cell
+// until it returns false.
+func (r *ClimateRepository) StreamPrefix(ctx context.Context, table, prefix string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	logging.FromContext(ctx).Debug("Reading from table", "table", table, "prefix", prefix)
 
-	// filters 1 version by default
-	filter := bigtable.LatestNFilter(1)
+	tbl := r.ClientInstance.Open(table)
 
-	if len(filterList) == 1 {
-		filter = filterList[0]
-	} else if len(filterList) > 1 {
-		filter = bigtable.ChainFilters(filterList...)
+	filter, versions, match, err := getFilter(filters)
+	if err != nil {
+		return err
+	}
+	fn, err = matching(filters, fn)
+	if err != nil {
+		return err
 	}
+	return streamRows(ctx, table, "prefix", 1, bigtable.PrefixRange(prefix), filter, versions, match, tbl, fn)
+}
 
+func (r *ClimateRepository) ReadRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error) {
 	var result []entity.BigtableOutput
-	err := tbl.ReadRows(ctx, bigtable.PrefixRange(prefix),
-		func(row bigtable.Row) bool {
-			for _, cols := range row {
-				for _, col := range cols {
-					output := entity.BigtableOutput{
-						Key:     row.Key(),
-						Created: col.Timestamp.Time().UTC(),
-						Value:   string(col.Value),
-					}
-					result = append(result, output)
-				}
-			}
-			return true
-		}, bigtable.RowFilter(filter))
+	err := r.StreamRows(ctx, table, dataType, areas, dates, filters, func(output entity.BigtableOutput) bool {
+		result = append(result, output)
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (r *ClimateRepository) ReadRows(ctx context.Context, table string, areas, dates []string, filters map[string]string) ([]entity.BigtableOutput, error) {
-	log.Printf("Reading from table %s with areas: %s and dates: %s", table, areas, dates)
+// StreamRows reads the keys of the areas like ReadRows, calling fn for each
+// cell until it returns false.
+func (r *ClimateRepository) StreamRows(ctx context.Context, table, dataType string, areas, dates []string, filters map[string]string, fn func(entity.BigtableOutput) bool) error {
+	logging.FromContext(ctx).Debug("Reading from table", "table", table, "datatype", dataType, "areas", areas, "dates", dates)
 
 	tbl := r.ClientInstance.Open(table)
 
-	filter, err := getFilter(filters)
+	filter, versions, match, err := getFilter(filters)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	fn, err = matching(filters, fn)
+	if err != nil {
+		return err
 	}
 
-	var output []entity.BigtableOutput
 	if len(dates) > 1 {
-		output, err = readRowRange(ctx, dates, areas, filter, tbl)
-	} else {
-		output, err = readNoRange(ctx, dates[0], areas, filter, tbl)
+		rowRangeList := rowRanges(dataType, dates, areas, filters["inclusive_end"] == "true")
+		return streamRows(ctx, table, "range", len(rowRangeList), rowRangeList, filter, versions, match, tbl, fn)
 	}
+	rowList := rowKeys(dataType, dates[0], areas)
+	return streamRows(ctx, table, "list", len(rowList), rowList, filter, versions, match, tbl, fn)
+}
+
+// Write applies a mutation per cell in a bulk request.
+func (r *ClimateRepository) Write(ctx context.Context, table string, inputs []entity.BigtableInput) (err error) {
+	ctx, span := tracing.Start(ctx, "ClimateRepository.write", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "bigtable"),
+		attribute.String("bigtable.table", table),
+		attribute.Int("bigtable.rows", len(inputs)),
+	))
+	defer func() { tracing.End(span, err) }()
+	logging.FromContext(ctx).Debug("Writing to table", "table", table, "rows", len(inputs))
+
+	keys := make([]string, len(inputs))
+	mutations := make([]*bigtable.Mutation, len(inputs))
+	now := bigtable.Now()
+	for i, input := range inputs {
+		timestamp := now
+		if !input.Created.IsZero() {
+			timestamp = bigtable.Time(input.Created).TruncateToMilliseconds()
+		}
+		keys[i] = input.Key
+		mutations[i] = bigtable.NewMutation()
+		mutations[i].Set(r.Family, r.Column, timestamp, []byte(input.Value))
+	}
+
+	errs, err := r.ClientInstance.Open(table).ApplyBulk(ctx, keys, mutations)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return output, nil
+	for i, rowErr := range errs {
+		if rowErr != nil {
+			return fmt.Errorf("writing %s: %w", keys[i], rowErr)
+		}
+	}
+	return nil
 }
 
-func readNoRange(ctx context.Context, date string, areas []string, filter bigtable.Filter, tbl *bigtable.Table) ([]entity.BigtableOutput, error) {
+func rowKeys(dataType, date string, areas []string) bigtable.RowList {
 	var rowList bigtable.RowList
 	for _, area := range areas {
-		rowList = append(rowList, "w/"+area+"/"+date)
+		rowList = append(rowList, dataType+"/"+area+"/"+date)
 	}
-
-	var result []entity.BigtableOutput
-	err := tbl.ReadRows(ctx, rowList,
-		func(row bigtable.Row) bool {
-			for _, cols := range row {
-				for _, col := range cols {
-					output := entity.BigtableOutput{
-						Key:     row.Key(),
-						Created: col.Timestamp.Time().UTC(),
-						Value:   string(col.Value),
-					}
-					result = append(result, output)
-				}
-			}
-			return true
-		}, bigtable.RowFilter(filter))
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
+	return rowList
 }
 
-func readRowRange(ctx context.Context, dates, areas []string, filter bigtable.Filter, tbl *bigtable.Table) ([]entity.BigtableOutput, error) {
+// rowRanges returns the range of keys of each area between the two dates. An
+// empty date leaves that end of the range open, up to the first or last key of
+// the area. The end is exclusive unless inclusiveEnd is set.
+func rowRanges(dataType string, dates, areas []string, inclusiveEnd bool) bigtable.RowRangeList {
 	var rowRangeList bigtable.RowRangeList
 	for _, area := range areas {
-		rowRangeList = append(rowRangeList, bigtable.NewRange("w/"+area+"/"+dates[0], "w/"+area+"/"+dates[1]))
+		areaPrefix := dataType + "/" + area + "/"
+		begin, end := areaPrefix+dates[0], prefixSuccessor(areaPrefix)
+		if dates[1] != "" {
+			end = areaPrefix + dates[1]
+			if inclusiveEnd {
+				// the smallest key after the end date
+				end += "\x00"
+			}
+		}
+		rowRangeList = append(rowRangeList, bigtable.NewRange(begin, end))
 	}
+	return rowRangeList
+}
 
-	var result []entity.BigtableOutput
-	err := tbl.ReadRows(ctx, rowRangeList,
+// streamRows reads the row set, holding keys or key ranges, calling fn for
+// each cell until it returns false. The read is traced and recorded in the
+// metrics labelled by rowSetType.
+//
+// Cells returned twice by an interleave filter are skipped, as are those match,
+// when not nil, does not select, and versions, when not zero, limits the cells
+// of each column after skipping them.
+func streamRows(ctx context.Context, table, rowSetType string, ranges int, rowSet bigtable.RowSet, filter bigtable.Filter, versions int, match func(bigtable.ReadItem) bool, tbl *bigtable.Table, fn func(entity.BigtableOutput) bool) error {
+	ctx, span := tracing.Start(ctx, "ClimateRepository.readRows", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "bigtable"),
+		attribute.String("bigtable.table", table),
+		attribute.String("bigtable.row_set", rowSetType),
+		attribute.Int("bigtable.ranges", ranges),
+		attribute.String("bigtable.filter", filter.String()),
+	))
+	start := time.Now()
+	var stats metrics.ReadStats
+
+	err := tbl.ReadRows(ctx, rowSet,
 		func(row bigtable.Row) bool {
+			stats.Rows++
 			for _, cols := range row {
-				for _, col := range cols {
+				column, count := "", 0
+				var last bigtable.ReadItem
+				for i, col := range cols {
+					stats.Cells++
+					stats.Bytes += len(col.Row) + len(col.Value)
+					if i > 0 && col.Column == last.Column && col.Timestamp == last.Timestamp {
+						continue
+					}
+					last = col
+					if match != nil && !match(col) {
+						continue
+					}
+					if col.Column != column {
+						column, count = col.Column, 0
+					}
+					if count++; versions > 0 && count > versions {
+						continue
+					}
 					output := entity.BigtableOutput{
 						Key:     row.Key(),
 						Created: col.Timestamp.Time().UTC(),
 						Value:   string(col.Value),
 					}
-					result = append(result, output)
+					if !fn(output) {
+						return false
+					}
 				}
 			}
 			return true
 		}, bigtable.RowFilter(filter))
+	metrics.ObserveRead(table, rowSetType, start, stats, err)
+	span.SetAttributes(
+		attribute.Int("bigtable.rows", stats.Rows),
+		attribute.Int("bigtable.cells", stats.Cells),
+		attribute.Int("bigtable.bytes", stats.Bytes),
+	)
+	tracing.End(span, err)
+	return err
+}
+
+// prefixSuccessor returns the smallest key greater than every key starting
+// with prefix.
+func prefixSuccessor(prefix string) string {
+	n := len(prefix)
+	for n > 0 && prefix[n-1] == 0xff {
+		n--
+	}
+	if n == 0 {
+		return ""
+	}
+	return prefix[:n-1] + string([]byte{prefix[n-1] + 1})
+}
+
+// matching wraps fn to skip, as they are scanned, the cells whose payload does
+// not match the where predicate of the filters.
+func matching(filters map[string]string, fn func(entity.BigtableOutput) bool) (func(entity.BigtableOutput) bool, error) {
+	where, ok := filters["where"]
+	if !ok {
+		return fn, nil
+	}
+	predicate, err := filterexpr.ParseWhere(where)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("wrong where predicate: %w", err)
 	}
-	return result, nil
+	return func(output entity.BigtableOutput) bool {
+		payload, err := entity.DecodePayload(output.Value)
+		if err != nil || !predicate.Match(payload) {
+			return true
+		}
+		return fn(output)
+	}, nil
 }
 
-func getFilter(filters map[string]string) (bigtable.Filter, error) {
+// getFilter returns the Bigtable filter of a read, chaining the filter
+// expression, with its dates in tz or else in the location of the keys, the
+// regexp on the keys and the issuance, then the number of versions of each
+// column streamRows must keep itself, or 0 when the filter keeps them, and the
+// match streamRows selects the cells with, or nil when the filter selects them,
+// as for the negated cell comparisons. The versions, 1 by default, are left to
+// streamRows when the expression may return a cell twice or when the match
+// selects the cells, since they are counted among those.
+func getFilter(filters map[string]string) (bigtable.Filter, int, func(bigtable.ReadItem) bool, error) {
 	var filterList []bigtable.Filter
+	var match func(bigtable.ReadItem) bool
+	clientVersions := false
 
-	if version, ok := filters["version"]; ok {
-		versionInt, err := strconv.Atoi(version)
+	if expression, ok := filters["filter"]; ok {
+		loc := entity.KeyLocation
+		if tz, ok := filters["tz"]; ok {
+			var err error
+			if loc, err = time.LoadLocation(tz); err != nil {
+				return nil, 0, nil, errors.New("wrong tz filter")
+			}
+		}
+		expr, err := filterexpr.Parse(expression, loc)
 		if err != nil {
-			return nil, errors.New("wrong version filter")
+			return nil, 0, nil, fmt.Errorf("wrong filter expression: %w", err)
+		}
+		filterList = append(filterList, expr.Filter())
+		clientVersions = filterexpr.MayDuplicate(expr)
+		// the versions are counted among the cells it matches
+		if !filterexpr.Exact(expr) {
+			match, clientVersions = expr.Match, true
 		}
-		filterList = append(filterList, bigtable.LatestNFilter(versionInt))
-	} else {
-		// filters 1 version by default
-		filterList = append(filterList, bigtable.LatestNFilter(1))
 	}
 
 	if regexp, ok := filters["regexp"]; ok {
 		filterList = append(filterList, bigtable.RowKeyFilter(regexp))
 	}
 
-	var filter bigtable.Filter
+	// the versions created up to the issuance, the latest being the forecast issued then
+	if issuedAt, ok := filters["issued_at"]; ok {
+		issued, err := time.Parse(time.RFC3339Nano, issuedAt)
+		if err != nil {
+			return nil, 0, nil, errors.New("wrong issued_at filter")
+		}
+		filterList = append(filterList, bigtable.TimestampRangeFilter(time.Time{}, issued.Add(time.Millisecond)))
+	}
+
+	// filters 1 version by default
+	versions := 1
+	if version, ok := filters["version"]; ok {
+		versionInt, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, 0, nil, errors.New("wrong version filter")
+		}
+		versions = versionInt
+	}
+	if clientVersions {
+		if len(filterList) == 1 {
+			return filterList[0], versions, match, nil
+		}
+		return bigtable.ChainFilters(filterList...), versions, match, nil
+	}
+	filterList = append(filterList, bigtable.LatestNFilter(versions))
+
 	if len(filterList) == 1 {
-		filter = filterList[0]
-	} else if len(filterList) > 1 {
-		filter = bigtable.ChainFilters(filterList...)
+		return filterList[0], 0, nil, nil
 	}
-	return filter, nil
+	return bigtable.ChainFilters(filterList...), 0, nil, nil
 }
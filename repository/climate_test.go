@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"bigtable_api/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixSuccessor(t *testing.T) {
+	assert.Equal(t, "w/A10", prefixSuccessor("w/A1/"))
+	assert.Equal(t, "w/A2", prefixSuccessor("w/A1\xff"))
+	assert.Equal(t, "", prefixSuccessor("\xff\xff"))
+
+	// every key of the area sorts before the successor, and keys of the next areas after it
+	assert.Less(t, "w/A1/2099-12-31 23:59:59", prefixSuccessor("w/A1/"))
+	assert.GreaterOrEqual(t, "w/A10/2023-01-01 00:00:00", prefixSuccessor("w/A1/"))
+}
+
+func TestGetFilter(t *testing.T) {
+	for _, test := range []struct {
+		filters  map[string]string
+		filter   string
+		versions int
+		matches  bool
+	}{
+		{map[string]string{}, "col(*,1)", 0, false},
+		// the prefix and the key reads chain the filters in the same order
+		{map[string]string{"regexp": ".*00:00:00", "version": "3"}, "(row(.*00:00:00) | col(*,3))", 0, false},
+		{map[string]string{"filter": `created < 2023-10-01`}, "(timestamp_range(0,1696118400000000) | col(*,1))", 0, false},
+		{map[string]string{"issued_at": "2023-10-01T00:00:00Z"}, "(timestamp_range(0,1696118400001000) | col(*,1))", 0, false},
+		// an interleave may return a cell twice, so the versions are counted by streamRows
+		{map[string]string{"filter": `key ~ ".*00:00" OR created < 2023-10-01`, "version": "2"}, "(timestamp_range(0,1696118400000000) + row(.*00:00))", 2, false},
+		{map[string]string{"filter": `created < 2023-10-01`, "tz": "America/Sao_Paulo"}, "(timestamp_range(0,1696129200000000) | col(*,1))", 0, false},
+		// the cells of a negated family are matched, and their versions counted, by streamRows
+		{map[string]string{"filter": `key ~ ".*00:00" AND NOT family = raw`}, "(row(.*00:00) | passAllFilter())", 1, true},
+	} {
+		filter, versions, match, err := getFilter(test.filters)
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.filter, filter.String())
+			assert.Equal(t, test.versions, versions)
+			assert.Equal(t, test.matches, match != nil)
+		}
+	}
+
+	_, _, _, err := getFilter(map[string]string{"filter": "key ="})
+	assert.Error(t, err)
+	_, _, _, err = getFilter(map[string]string{"filter": "created < 2023-10-01", "tz": "Mars/Olympus"})
+	assert.Error(t, err)
+}
+
+func TestMatching(t *testing.T) {
+	var keys []string
+	fn, err := matching(map[string]string{"where": "rain > 0 OR temperatureMin < 2"}, func(output entity.BigtableOutput) bool {
+		keys = append(keys, output.Key)
+		return len(keys) < 2
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, output := range []entity.BigtableOutput{
+		{Key: "w/A1/2023-07-01 06:00:00", Value: `{"weatherData":{"temperatureMin":1.2,"rain":0}}`},
+		{Key: "w/A1/2023-07-01 07:00:00", Value: `{"weatherData":{"temperatureMin":4.8,"rain":0}}`},
+		{Key: "w/A1/2023-07-01 08:00:00", Value: `not a payload`},
+		{Key: "w/A1/2023-07-01 09:00:00", Value: `{"weatherData":{"temperatureMin":9.1,"rain":0.2}}`},
+	} {
+		if !fn(output) {
+			break
+		}
+	}
+	// the skipped cells do not stop the scan, while fn still can
+	assert.Equal(t, []string{"w/A1/2023-07-01 06:00:00", "w/A1/2023-07-01 09:00:00"}, keys)
+
+	_, err = matching(map[string]string{"where": "rain >"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// Probe reads a single key from the table, without its value, to check that
+// Bigtable is reachable and the credentials are valid.
+func (r *ClimateRepository) Probe(ctx context.Context, table string) error {
+	tbl := r.ClientInstance.Open(table)
+	return tbl.ReadRows(ctx, bigtable.InfiniteRange(""),
+		func(row bigtable.Row) bool { return false },
+		bigtable.LimitRows(1),
+		bigtable.RowFilter(bigtable.ChainFilters(bigtable.LatestNFilter(1), bigtable.StripValueFilter())))
+}
+
+// AdminProbe checks that the admin client can describe the table.
+func AdminProbe(adminClient *bigtable.AdminClient, table string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := adminClient.TableInfo(ctx, table)
+		return err
+	}
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"bigtable_api/climatepb"
+	"bigtable_api/handlers"
+	"bigtable_api/logging"
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// InitializeGRPC builds the gRPC server. The interceptors, usually the
+// authentication ones, run after the logging of the calls.
+func InitializeGRPC(climateServer *handlers.ClimateGRPCServer, unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(append([]grpc.UnaryServerInterceptor{logUnary}, unary...)...),
+		grpc.ChainStreamInterceptor(append([]grpc.StreamServerInterceptor{logStream}, stream...)...),
+	)
+	climatepb.RegisterClimateServiceServer(server, climateServer)
+	// lets tools such as grpcurl list the services
+	reflection.Register(server)
+	return server
+}
+
+func logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = logging.With(ctx, "grpc_method", info.FullMethod)
+	resp, err := handler(ctx, req)
+	logCall(ctx, start, err)
+	return resp, err
+}
+
+func logStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := logging.With(stream.Context(), "grpc_method", info.FullMethod)
+	err := handler(srv, &loggingStream{ServerStream: stream, ctx: ctx})
+	logCall(ctx, start, err)
+	return err
+}
+
+// logCall logs a served call like the "request served" entry of the REST API.
+func logCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	logging.FromContext(ctx).Log(ctx, level, "call served", "grpc_code", code.String(), "latency", time.Since(start))
+}
+
+// loggingStream carries the context with the call logger.
+type loggingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggingStream) Context() context.Context {
+	return s.ctx
+}
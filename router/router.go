@@ -1,18 +1,58 @@
 package router
 
 import (
+	"bigtable_api/auth"
 	"bigtable_api/handlers"
+	"bigtable_api/logging"
+	"bigtable_api/metrics"
+	"bigtable_api/tracing"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func InitializeRouter(climateHandler *handlers.ClimateHandler) *gin.Engine {
-	router := gin.Default()
+// InitializeRouter builds the API routes. The middlewares, usually the
+// authentication ones, guard every route but the status, health and metrics ones.
+// The OGC API-EDR routes are left out when edrHandler is nil.
+func InitializeRouter(climateHandler *handlers.ClimateHandler, edrHandler *handlers.EDRHandler, healthHandler *handlers.HealthHandler, middlewares ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	// lets the request context, and the logger it carries, reach the lower layers through *gin.Context
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware(tracing.ServiceName), logging.RequestID(), metrics.Middleware(), gin.Recovery())
 	router.NoRoute(func(ctx *gin.Context) { ctx.JSON(http.StatusNotFound, gin.H{"message": "page not found"}) })
 	router.GET("/", func(ctx *gin.Context) { ctx.JSON(http.StatusOK, "up and running...") })
+	router.GET("/metrics", metrics.Handler())
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
 
-	read := router.Group("/read")
-	read.GET("/climate-data", climateHandler.ReadClimateData)
+	read := router.Group("/read", middlewares...)
+	read.GET("/climate-data", auth.Require(auth.OperationRead), climateHandler.ReadClimateData)
+
+	query := router.Group("/query", middlewares...)
+	query.POST("/climate-data", auth.Require(auth.OperationRead), climateHandler.QueryClimateData)
+
+	batch := router.Group("/batch", middlewares...)
+	batch.POST("", auth.Require(auth.OperationRead), climateHandler.Batch)
+
+	compare := router.Group("/compare", middlewares...)
+	compare.GET("", auth.Require(auth.OperationRead), climateHandler.Compare)
+	compare.GET("/skill", auth.Require(auth.OperationRead), climateHandler.Skill)
+
+	agro := router.Group("/agro", middlewares...)
+	agro.GET("/gdd", auth.Require(auth.OperationRead), climateHandler.GrowingDegreeDays)
+	agro.GET("/chill", auth.Require(auth.OperationRead), climateHandler.Chill)
+	agro.GET("/et0", auth.Require(auth.OperationRead), climateHandler.ReferenceEvapotranspiration)
+	agro.GET("/water-balance", auth.Require(auth.OperationRead), climateHandler.WaterBalance)
+
+	if edrHandler != nil {
+		edr := router.Group("/collections", middlewares...)
+		edr.Use(auth.Require(auth.OperationRead))
+		edr.GET("", edrHandler.Collections)
+		edr.GET("/:collectionId", edrHandler.Collection)
+		edr.GET("/:collectionId/locations", edrHandler.Locations)
+		edr.GET("/:collectionId/locations/:locationId", edrHandler.Location)
+		edr.GET("/:collectionId/position", edrHandler.Position)
+	}
 	return router
 }
@@ -2,7 +2,8 @@ package server
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,11 +11,18 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 type Server struct {
 	Port   string
 	Router *gin.Engine
+	// DrainDelay is how long the server keeps serving after the shutdown
+	// hooks ran, so that load balancers notice the instance is not ready.
+	DrainDelay time.Duration
+	onShutdown []func()
+	grpcPort   string
+	grpc       *grpc.Server
 }
 
 type HandlerDetails struct {
@@ -29,6 +37,17 @@ func NewServer(port string, router *gin.Engine) *Server {
 	}
 }
 
+// OnShutdown registers f to be called as soon as a shutdown signal arrives.
+func (s *Server) OnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// ServeGRPC also serves a gRPC server on port, stopped gracefully with the
+// HTTP one.
+func (s *Server) ServeGRPC(port string, server *grpc.Server) {
+	s.grpcPort, s.grpc = port, server
+}
+
 func (s *Server) Start() {
 	srv := &http.Server{
 		Addr:    s.Port,
@@ -36,26 +55,74 @@ func (s *Server) Start() {
 	}
 
 	go func() {
-		log.Println("Listening and serving on port: ", s.Port)
+		slog.Info("Listening and serving", "port", s.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server error: %v", err)
+			slog.Error("HTTP server error", "error", err)
+			os.Exit(1)
 		}
-		log.Println("Stopped serving new connections.")
+		slog.Info("Stopped serving new connections.")
 	}()
 
+	if s.grpc != nil {
+		listener, err := net.Listen("tcp", s.grpcPort)
+		if err != nil {
+			slog.Error("gRPC listen error", "error", err)
+			os.Exit(1)
+		}
+		go func() {
+			slog.Info("Serving gRPC", "port", s.grpcPort)
+			if err := s.grpc.Serve(listener); err != nil {
+				slog.Error("gRPC server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	// will block the app until receives a signal from the O.S.
 	<-quit
 
+	for _, f := range s.onShutdown {
+		f()
+	}
+	if s.DrainDelay > 0 {
+		slog.Info("Draining before shutdown", "delay", s.DrainDelay)
+		time.Sleep(s.DrainDelay)
+	}
+
 	// cancel will release all resources associated with the context
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server...")
+	grpcStopped := make(chan struct{})
+	go func() {
+		if s.grpc != nil {
+			s.stopGRPC(ctx)
+		}
+		close(grpcStopped)
+	}()
 	// gracefully stop accepting new requests and waits for the active ones to be handled
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("HTTP shutdown error: %v", err)
+		slog.Error("HTTP shutdown error", "error", err)
+		os.Exit(1)
+	}
+	<-grpcStopped
+	slog.Info("Graceful shutdown complete.")
+}
+
+// stopGRPC waits for the active calls, closing the remaining ones when ctx is done.
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("gRPC shutdown timeout, closing the active calls")
+		s.grpc.Stop()
 	}
-	log.Println("Graceful shutdown complete.")
 }
@@ -0,0 +1,102 @@
+package stations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// earthRadius is the mean radius of the Earth in kilometers.
+const earthRadius = 6371.0
+
+// Station is an area of the climate data with a fixed position.
+type Station struct {
+	AreaID string  `json:"area_id"`
+	Name   string  `json:"name,omitempty"`
+	Lon    float64 `json:"lon"`
+	Lat    float64 `json:"lat"`
+	// Elevation is in meters above sea level.
+	Elevation float64 `json:"elevation,omitempty"`
+}
+
+type catalogFile struct {
+	Stations []Station `json:"stations"`
+}
+
+// Catalog holds the stations read from a JSON file, sorted by area ID. A nil
+// Catalog has no station.
+type Catalog struct {
+	stations []Station
+	byArea   map[string]Station
+}
+
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return NewCatalog(file.Stations)
+}
+
+func NewCatalog(stations []Station) (*Catalog, error) {
+	catalog := &Catalog{byArea: make(map[string]Station, len(stations))}
+	for _, station := range stations {
+		if station.Lon < -180 || station.Lon > 180 || station.Lat < -90 || station.Lat > 90 {
+			return nil, fmt.Errorf("station %s: invalid position %v %v", station.AreaID, station.Lon, station.Lat)
+		}
+		if _, ok := catalog.byArea[station.AreaID]; ok {
+			return nil, fmt.Errorf("station %s: duplicated", station.AreaID)
+		}
+		catalog.byArea[station.AreaID] = station
+		catalog.stations = append(catalog.stations, station)
+	}
+	sort.Slice(catalog.stations, func(i, j int) bool { return catalog.stations[i].AreaID < catalog.stations[j].AreaID })
+	return catalog, nil
+}
+
+func (c *Catalog) All() []Station {
+	if c == nil {
+		return nil
+	}
+	return c.stations
+}
+
+func (c *Catalog) Get(areaID string) (Station, bool) {
+	if c == nil {
+		return Station{}, false
+	}
+	station, ok := c.byArea[areaID]
+	return station, ok
+}
+
+// Nearest returns the station closest to a position among those allowed
+// reports true for, and its distance in kilometers, and false when there is
+// none.
+func (c *Catalog) Nearest(lon, lat float64, allowed func(Station) bool) (Station, float64, bool) {
+	var nearest Station
+	distance := math.Inf(1)
+	for _, station := range c.All() {
+		if !allowed(station) {
+			continue
+		}
+		if d := Distance(lon, lat, station.Lon, station.Lat); d < distance {
+			nearest, distance = station, d
+		}
+	}
+	return nearest, distance, !math.IsInf(distance, 1)
+}
+
+// Distance is the great-circle distance in kilometers between two positions.
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	radians := math.Pi / 180
+	dLat := (lat2 - lat1) * radians
+	dLon := (lon2 - lon1) * radians
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*radians)*math.Cos(lat2*radians)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,71 @@
+package stations_test
+
+import (
+	"bigtable_api/stations"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CatalogSuite struct {
+	suite.Suite
+}
+
+func TestCatalogSuite(t *testing.T) {
+	suite.Run(t, new(CatalogSuite))
+}
+
+func (c *CatalogSuite) TestLoad() {
+	path := filepath.Join(c.T().TempDir(), "stations.json")
+	c.Nil(os.WriteFile(path, []byte(`{"stations":[
+		{"area_id":"A327735","name":"Rio Verde","lon":-50.9,"lat":-17.8},
+		{"area_id":"A327734","name":"Jatai","lon":-50.59667,"lat":-17.749189,"elevation":680}
+	]}`), 0o600))
+	catalog, err := stations.LoadCatalog(path)
+	c.Require().Nil(err)
+	c.Len(catalog.All(), 2)
+	c.Equal("A327734", catalog.All()[0].AreaID)
+
+	station, ok := catalog.Get("A327734")
+	c.True(ok)
+	c.Equal(680.0, station.Elevation)
+	_, ok = catalog.Get("A1")
+	c.False(ok)
+}
+
+func (c *CatalogSuite) TestNearest() {
+	catalog, err := stations.NewCatalog([]stations.Station{
+		{AreaID: "A327734", Lon: -50.59667, Lat: -17.749189},
+		{AreaID: "A327735", Lon: -50.9, Lat: -17.8},
+	})
+	c.Require().Nil(err)
+	all := func(stations.Station) bool { return true }
+	station, distance, ok := catalog.Nearest(-50.6, -17.75, all)
+	c.True(ok)
+	c.Equal("A327734", station.AreaID)
+	c.InDelta(0.36, distance, 0.01)
+
+	// the stations that are not allowed are skipped
+	station, _, ok = catalog.Nearest(-50.6, -17.75, func(s stations.Station) bool { return s.AreaID != "A327734" })
+	c.True(ok)
+	c.Equal("A327735", station.AreaID)
+	_, _, ok = catalog.Nearest(-50.6, -17.75, func(stations.Station) bool { return false })
+	c.False(ok)
+
+	_, _, ok = (*stations.Catalog)(nil).Nearest(-50.6, -17.75, all)
+	c.False(ok)
+}
+
+func (c *CatalogSuite) TestInvalid() {
+	_, err := stations.NewCatalog([]stations.Station{{AreaID: "A1", Lon: -200}})
+	c.NotNil(err)
+	_, err = stations.NewCatalog([]stations.Station{{AreaID: "A1"}, {AreaID: "A1"}})
+	c.NotNil(err)
+}
+
+func (c *CatalogSuite) TestDistance() {
+	// Brasilia to Sao Paulo
+	c.InDelta(873, stations.Distance(-47.93, -15.78, -46.63, -23.55), 5)
+}
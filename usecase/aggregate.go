@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Aggregation intervals and functions.
+var (
+	AggregationIntervals = []string{"hour", "day", "week", "month"}
+	AggregationFunctions = []string{"avg", "min", "max", "sum", "count"}
+)
+
+// Aggregator summarizes the variables of the cells of each area per time
+// bucket. Cells must arrive sorted by key, as Bigtable reads them, so a bucket
+// is emitted as soon as a cell of another one arrives.
+//
+// A bucket is emitted as a cell keyed by its start, whose payload holds the
+// position of the area and a variable per function, such as temperatureInst_avg.
+type Aggregator struct {
+	interval  string
+	functions []string
+	loc       *time.Location
+	emit      func(entity.BigtableOutput) bool
+
+	lastKey string
+	bucket  *bucket
+}
+
+type bucket struct {
+	dataType string
+	areaID   string
+	start    time.Time
+	created  time.Time
+	lonlat   []float64
+	stats    map[string]*stats
+}
+
+type stats struct {
+	count         int
+	sum, min, max float64
+}
+
+func NewAggregator(interval string, functions []string, loc *time.Location, emit func(entity.BigtableOutput) bool) *Aggregator {
+	return &Aggregator{interval: interval, functions: functions, loc: loc, emit: emit}
+}
+
+// Add accounts a cell, returning false when emit asked to stop. Older
+// versions of a key are skipped.
+func (a *Aggregator) Add(output entity.BigtableOutput) bool {
+	if output.Key == a.lastKey {
+		return true
+	}
+	a.lastKey = output.Key
+	key, err := entity.ParseKey(output.Key)
+	if err != nil {
+		return true
+	}
+	payload, err := entity.DecodePayload(output.Value)
+	if err != nil {
+		return true
+	}
+
+	start := a.start(key.Date)
+	if a.bucket != nil && (a.bucket.areaID != key.AreaID || a.bucket.dataType != key.DataType || !a.bucket.start.Equal(start)) {
+		if !a.Flush() {
+			return false
+		}
+	}
+	if a.bucket == nil {
+		a.bucket = &bucket{dataType: key.DataType, areaID: key.AreaID, start: start, stats: make(map[string]*stats)}
+	}
+	b := a.bucket
+	if output.Created.After(b.created) {
+		b.created = output.Created
+	}
+	if b.lonlat == nil && payload.LonLat != nil {
+		b.lonlat = payload.LonLat
+	}
+	for name, value := range payload.Variables {
+		s, ok := b.stats[name]
+		if !ok {
+			s = &stats{min: math.Inf(1), max: math.Inf(-1)}
+			b.stats[name] = s
+		}
+		s.count++
+		s.sum += value
+		s.min = math.Min(s.min, value)
+		s.max = math.Max(s.max, value)
+	}
+	return true
+}
+
+// Flush emits the current bucket, returning false when emit asked to stop.
+func (a *Aggregator) Flush() bool {
+	b := a.bucket
+	if b == nil {
+		return true
+	}
+	a.bucket = nil
+
+	values := make(map[string]float64, len(b.stats)*len(a.functions))
+	for name, s := range b.stats {
+		for _, function := range a.functions {
+			switch function {
+			case "avg":
+				values[name+"_avg"] = s.sum / float64(s.count)
+			case "min":
+				values[name+"_min"] = s.min
+			case "max":
+				values[name+"_max"] = s.max
+			case "sum":
+				values[name+"_sum"] = s.sum
+			case "count":
+				values[name+"_count"] = float64(s.count)
+			}
+		}
+	}
+	payload := map[string]interface{}{"aggregates": values}
+	if b.lonlat != nil {
+		payload["lonlat"] = b.lonlat
+	}
+	value, _ := json.Marshal(payload)
+	return a.emit(entity.BigtableOutput{
+		Key:     entity.Key{DataType: b.dataType, AreaID: b.areaID, Date: b.start}.String(),
+		Created: b.created,
+		Value:   string(value),
+	})
+}
+
+// start returns the start of the bucket of a date, in the aggregator timezone.
+// Weeks start on Monday.
+func (a *Aggregator) start(date time.Time) time.Time {
+	t := date.In(a.loc)
+	switch a.interval {
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, a.loc)
+	case "week":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-weekday, 0, 0, 0, 0, a.loc)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, a.loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, a.loc)
+	}
+}
@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/meteo"
+	"bigtable_api/tracing"
+	"context"
+	"math"
+	"time"
+)
+
+// Models of the chill accumulation.
+const (
+	ChillHours = "hours"
+	ChillUtah  = "utah"
+)
+
+// minDayHours is the number of hourly readings of a variable a day needs for
+// the indices: the extremes and sums of the days with fewer would be biased,
+// so those days are left out.
+const minDayHours = 20
+
+// validRanges bound the values of the variables the indices use, outside of
+// which the readings come from faulty sensors and are dropped.
+var validRanges = map[string][2]float64{
+	"temperatureInst":         {-60, 60},
+	"temperatureMin":          {-60, 60},
+	"temperatureMax":          {-60, 60},
+	"humidityInst":            {0, 100},
+	"humidityMin":             {0, 100},
+	"humidityMax":             {0, 100},
+	"atmosphericPressureInst": {600, 1100},
+	"windSpeedInst":           {0, 75},
+	"solarIrradiation":        {0, 1400},
+	"rain":                    {0, 350},
+}
+
+// GDDOptions are the options of the growing degree days.
+type GDDOptions struct {
+	// Base is the temperature from which the crop develops, and Cap the one
+	// above which it does not develop faster, in degC.
+	Base, Cap float64
+	// Method is meteo.GDDAverage or meteo.GDDTriangle.
+	Method string
+}
+
+// day holds the hourly payloads of an area in a local day.
+type day struct {
+	areaID string
+	date   time.Time
+	lonlat []float64
+	hours  []entity.Payload
+}
+
+// days reads the latest version of the observed cells of the areas between
+// the dates, and calls fn with those of each area and day in loc, in order,
+// until it returns false. The values out of their valid range are dropped.
+func (c *ClimateUsecase) days(ctx context.Context, table string, areas, dates []string, loc *time.Location, fn func(day) bool) error {
+	var current *day
+	lastKey := ""
+	stopped := false
+	err := c.gateway.StreamRows(ctx, table, "w", areas, dates, map[string]string{}, func(output entity.BigtableOutput) bool {
+		if output.Key == lastKey {
+			return true
+		}
+		lastKey = output.Key
+		key, err := entity.ParseKey(output.Key)
+		if err != nil {
+			return true
+		}
+		payload, err := entity.DecodePayload(output.Value)
+		if err != nil {
+			return true
+		}
+		dropInvalid(payload.Variables)
+		local := key.Date.In(loc)
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		if current != nil && (current.areaID != key.AreaID || !current.date.Equal(date)) {
+			if !fn(*current) {
+				stopped = true
+				return false
+			}
+			current = nil
+		}
+		if current == nil {
+			current = &day{areaID: key.AreaID, date: date}
+		}
+		if current.lonlat == nil {
+			current.lonlat = payload.LonLat
+		}
+		current.hours = append(current.hours, payload)
+		return true
+	})
+	if err == nil && current != nil && !stopped {
+		fn(*current)
+	}
+	return err
+}
+
+// accumulate builds the series of an index of each area, in the order of the
+// areas, from the values of its days. value returns false for the days whose
+// index can not be computed, such as those with fewer than minDayHours
+// readings, which are left out of the totals.
+func (c *ClimateUsecase) accumulate(ctx context.Context, table string, areas, dates []string, loc *time.Location, index, units string, value func(day) (entity.AgroDay, bool)) ([]entity.AgroSeries, error) {
+	series := make(map[string]*entity.AgroSeries, len(areas))
+	result := make([]entity.AgroSeries, len(areas))
+	for i, area := range areas {
+		result[i] = entity.AgroSeries{AreaID: area, Index: index, Units: units, Days: []entity.AgroDay{}}
+		series[area] = &result[i]
+	}
+	err := c.days(ctx, table, areas, dates, loc, func(d day) bool {
+		s, ok := series[d.areaID]
+		if !ok {
+			return true
+		}
+		agroDay, ok := value(d)
+		if !ok {
+			return true
+		}
+		agroDay.Date = d.date.Format("2006-01-02")
+		agroDay.Hours = len(d.hours)
+		agroDay.Value = round(agroDay.Value)
+		s.Total = round(s.Total + agroDay.Value)
+		agroDay.Cumulative = s.Total
+		s.Days = append(s.Days, agroDay)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GrowingDegreeDays accumulates the growing degree days of the areas by day in
+// loc, from the extremes of the hourly temperatures.
+func (c *ClimateUsecase) GrowingDegreeDays(ctx context.Context, table string, areas, dates []string, loc *time.Location, options GDDOptions) (series []entity.AgroSeries, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.GrowingDegreeDays")
+	defer func() { tracing.End(span, err) }()
+
+	return c.accumulate(ctx, table, areas, dates, loc, "gdd", "degC day", func(d day) (entity.AgroDay, bool) {
+		if !complete(d.hours, "temperature") {
+			return entity.AgroDay{}, false
+		}
+		tmin, tmax, ok := extremes(d.hours, "temperature")
+		if !ok {
+			return entity.AgroDay{}, false
+		}
+		return entity.AgroDay{
+			Value:          meteo.GrowingDegreeDays(tmin, tmax, options.Base, options.Cap, options.Method),
+			TemperatureMin: &tmin,
+			TemperatureMax: &tmax,
+		}, true
+	})
+}
+
+// Chill accumulates the chill of the areas by day in loc, from the hourly
+// temperatures, in chill hours or in Utah chill units.
+func (c *ClimateUsecase) Chill(ctx context.Context, table string, areas, dates []string, loc *time.Location, model string) (series []entity.AgroSeries, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Chill")
+	defer func() { tracing.End(span, err) }()
+
+	index, units := "chill_hours", "h"
+	if model == ChillUtah {
+		index, units = "utah_chill_units", "1"
+	}
+	return c.accumulate(ctx, table, areas, dates, loc, index, units, func(d day) (entity.AgroDay, bool) {
+		if !complete(d.hours, "temperatureInst") {
+			return entity.AgroDay{}, false
+		}
+		var chill float64
+		for _, hour := range d.hours {
+			t, ok := hour.Variables["temperatureInst"]
+			if !ok {
+				continue
+			}
+			switch {
+			case model == ChillUtah:
+				chill += meteo.UtahChillUnits(t)
+			case meteo.ChillHour(t):
+				chill++
+			}
+		}
+		return entity.AgroDay{Value: chill}, true
+	})
+}
+
+// extremes returns the minimum and maximum of a variable over the hours, from
+// its extremes, such as temperatureMin and temperatureMax or, without them,
+// its instant values, such as temperatureInst.
+func extremes(hours []entity.Payload, variable string) (low, high float64, ok bool) {
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, hour := range hours {
+		instant, hasInstant := hour.Variables[variable+"Inst"]
+		if value, found := hour.Variables[variable+"Min"]; found {
+			low = math.Min(low, value)
+		} else if hasInstant {
+			low = math.Min(low, instant)
+		}
+		if value, found := hour.Variables[variable+"Max"]; found {
+			high = math.Max(high, value)
+		} else if hasInstant {
+			high = math.Max(high, instant)
+		}
+	}
+	return low, high, !math.IsInf(low, 0) && !math.IsInf(high, 0)
+}
+
+// dropInvalid removes the variables whose value is out of their valid range.
+func dropInvalid(variables map[string]float64) {
+	for name, value := range variables {
+		if bounds, ok := validRanges[name]; ok && (value < bounds[0] || value > bounds[1]) {
+			delete(variables, name)
+		}
+	}
+}
+
+// complete tells whether minDayHours of the hours hold a variable, or one of
+// its instant and extreme values, such as temperatureInst for temperature.
+func complete(hours []entity.Payload, variable string) bool {
+	readings := 0
+	for _, hour := range hours {
+		for _, name := range []string{variable, variable + "Inst", variable + "Min", variable + "Max"} {
+			if _, found := hour.Variables[name]; found {
+				readings++
+				break
+			}
+		}
+	}
+	return readings >= minDayHours
+}
+
+// round rounds an index to hundredths.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
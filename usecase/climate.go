@@ -3,40 +3,111 @@ package usecase
 import (
 	"bigtable_api/entity"
 	"bigtable_api/gateway"
+	"bigtable_api/tracing"
 	"context"
+	"time"
 )
 
 type ClimateUsecase struct {
 	gateway gateway.ClimateGateway
+	// Now is the time Latest searches back from, time.Now when nil.
+	Now func() time.Time
 }
 
 func NewClimateUsecase(gateway gateway.ClimateGateway) *ClimateUsecase {
 	return &ClimateUsecase{gateway: gateway}
 }
 
-func (c *ClimateUsecase) ReadPrefix(ctx context.Context, table string, filters map[string]string, prefixes ...string) ([]entity.BigtableOutput, error) {
-	var prefix string
-	for _, prefixPart := range prefixes {
-		if prefixPart != "" {
-			if prefix == "" {
-				prefix += prefixPart
+func (c *ClimateUsecase) ReadPrefix(ctx context.Context, table string, filters map[string]string, prefixes ...string) (output []entity.BigtableOutput, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.ReadPrefix")
+	defer func() { tracing.End(span, err) }()
 
-			} else {
-				prefix += "/" + prefixPart
-			}
-		}
-	}
-	output, err := c.gateway.ReadPrefix(ctx, table, prefix, filters)
+	output, err = c.gateway.ReadPrefix(ctx, table, joinPrefix(prefixes), filters)
 	if err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
-func (c *ClimateUsecase) Read(ctx context.Context, table string, filters map[string]string, areas, dates []string) ([]entity.BigtableOutput, error) {
-	output, err := c.gateway.ReadRows(ctx, table, areas, dates, filters)
+func (c *ClimateUsecase) Read(ctx context.Context, table, dataType string, filters map[string]string, areas, dates []string) (output []entity.BigtableOutput, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Read")
+	defer func() { tracing.End(span, err) }()
+
+	output, err = c.gateway.ReadRows(ctx, table, dataType, areas, dates, filters)
 	if err != nil {
 		return nil, err
 	}
 	return output, nil
 }
+
+// StreamPrefix reads like ReadPrefix, calling fn for each cell until it
+// returns false, so that large scans are not held in memory.
+func (c *ClimateUsecase) StreamPrefix(ctx context.Context, table string, filters map[string]string, fn func(entity.BigtableOutput) bool, prefixes ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.StreamPrefix")
+	defer func() { tracing.End(span, err) }()
+
+	return c.gateway.StreamPrefix(ctx, table, joinPrefix(prefixes), filters, fn)
+}
+
+// Stream reads like Read, calling fn for each cell until it returns false.
+func (c *ClimateUsecase) Stream(ctx context.Context, table, dataType string, filters map[string]string, areas, dates []string, fn func(entity.BigtableOutput) bool) (err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Stream")
+	defer func() { tracing.End(span, err) }()
+
+	return c.gateway.StreamRows(ctx, table, dataType, areas, dates, filters, fn)
+}
+
+// latestWindows are the spans before now searched, in turn, for the most
+// recent key of an area, so that Latest reads a year of keys at most.
+var latestWindows = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 31 * 24 * time.Hour, 366 * 24 * time.Hour}
+
+// Latest returns the latest version of the most recent key of an area up to
+// now, which for forecasts is the latest issued forecast of the current hour
+// rather than the furthest one ahead, and false when the area has no key in
+// the last year.
+func (c *ClimateUsecase) Latest(ctx context.Context, table, dataType, area string) (output entity.BigtableOutput, found bool, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Latest")
+	defer func() { tracing.End(span, err) }()
+
+	latest := func(cell entity.BigtableOutput) bool {
+		if !found || cell.Key > output.Key || cell.Key == output.Key && cell.Created.After(output.Created) {
+			output, found = cell, true
+		}
+		return true
+	}
+	now := time.Now()
+	if c.Now != nil {
+		now = c.Now()
+	}
+	filters := map[string]string{"inclusive_end": "true"}
+	for _, window := range latestWindows {
+		dates := []string{entity.FormatKeyDate(now.Add(-window)), entity.FormatKeyDate(now)}
+		if err = c.gateway.StreamRows(ctx, table, dataType, []string{area}, dates, filters, latest); err != nil || found {
+			return output, found, err
+		}
+	}
+	return output, false, nil
+}
+
+// Write stores the cells.
+func (c *ClimateUsecase) Write(ctx context.Context, table string, inputs []entity.BigtableInput) (err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Write")
+	defer func() { tracing.End(span, err) }()
+
+	return c.gateway.Write(ctx, table, inputs)
+}
+
+func joinPrefix(prefixes []string) string {
+	var prefix string
+	for _, prefixPart := range prefixes {
+		if prefixPart != "" {
+			if prefix == "" {
+				prefix += prefixPart
+
+			} else {
+				prefix += "/" + prefixPart
+			}
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/tracing"
+	"context"
+	"strings"
+)
+
+// Pair reads the forecast cells of the areas between the dates, calling fn
+// with each of them and the latest observed cell of the same area and date,
+// until it returns false. Forecasts without an observation are skipped.
+//
+// The filters apply to the forecasts, while the observations are read in their
+// latest version. They are held in memory, one per key, as the forecasts are
+// streamed.
+func (c *ClimateUsecase) Pair(ctx context.Context, table string, areas, dates []string, filters map[string]string, fn func(forecast, observed entity.BigtableOutput) bool) (err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.Pair")
+	defer func() { tracing.End(span, err) }()
+
+	observedFilters := make(map[string]string)
+	if inclusiveEnd, ok := filters["inclusive_end"]; ok {
+		observedFilters["inclusive_end"] = inclusiveEnd
+	}
+	observed := make(map[string]entity.BigtableOutput)
+	err = c.gateway.StreamRows(ctx, table, "w", areas, dates, observedFilters, func(output entity.BigtableOutput) bool {
+		date := strings.TrimPrefix(output.Key, "w/")
+		if _, ok := observed[date]; !ok {
+			observed[date] = output
+		}
+		return true
+	})
+	if err != nil || len(observed) == 0 {
+		return err
+	}
+	return c.gateway.StreamRows(ctx, table, "f", areas, dates, filters, func(forecast entity.BigtableOutput) bool {
+		observation, ok := observed[strings.TrimPrefix(forecast.Key, "f/")]
+		if !ok {
+			return true
+		}
+		return fn(forecast, observation)
+	})
+}
+
+// Compare lines up the latest forecast and observation of the areas at each
+// date between the dates. Without variables, the ones of both payloads are
+// compared.
+func (c *ClimateUsecase) Compare(ctx context.Context, table string, areas, dates []string, filters map[string]string, variables []string) ([]entity.Comparison, error) {
+	comparisons := []entity.Comparison{}
+	lastKey := ""
+	err := c.Pair(ctx, table, areas, dates, filters, func(forecast, observed entity.BigtableOutput) bool {
+		// older versions of a forecast follow the latest one
+		if forecast.Key == lastKey {
+			return true
+		}
+		lastKey = forecast.Key
+		key, err := entity.ParseKey(forecast.Key)
+		if err != nil {
+			return true
+		}
+		forecastPayload, err := entity.DecodePayload(forecast.Value)
+		if err != nil {
+			return true
+		}
+		observedPayload, err := entity.DecodePayload(observed.Value)
+		if err != nil {
+			return true
+		}
+		names := variables
+		if len(names) == 0 {
+			for _, name := range forecastPayload.VariableNames() {
+				if _, ok := observedPayload.Variables[name]; ok {
+					names = append(names, name)
+				}
+			}
+		}
+		comparison := entity.Comparison{
+			AreaID:          key.AreaID,
+			Date:            entity.FormatKeyDate(key.Date),
+			ForecastCreated: forecast.Created,
+			ObservedCreated: observed.Created,
+			Variables:       make(map[string]entity.VariablePair, len(names)),
+		}
+		for _, name := range names {
+			comparison.Variables[name] = entity.NewVariablePair(name, forecastPayload, observedPayload)
+		}
+		comparisons = append(comparisons, comparison)
+		return true
+	})
+	return comparisons, err
+}
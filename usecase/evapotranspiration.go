@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"bigtable_api/meteo"
+	"bigtable_api/stations"
+	"bigtable_api/tracing"
+	"context"
+	"time"
+)
+
+// ET0Options are the options of the reference evapotranspiration.
+type ET0Options struct {
+	// WindHeight is the height, in meters, at which the wind is measured.
+	WindHeight float64
+	// Stations give the elevation of the areas, and their latitude when the
+	// payloads have no position.
+	Stations *stations.Catalog
+}
+
+// WaterBalanceOptions are the options of the soil water balance.
+type WaterBalanceOptions struct {
+	ET0Options
+	// Capacity is the water, in mm, the soil holds, and Initial the water it
+	// holds before the first day.
+	Capacity, Initial float64
+}
+
+// whToMJ converts the hourly irradiation of the payloads, in W h m-2, to the
+// MJ m-2 of FAO-56.
+const whToMJ = 0.0036
+
+// dailyWeather gathers the inputs of the reference evapotranspiration of a
+// day, and false when one was read in fewer than minDayHours hours. The
+// radiation of the day is the sum of the hourly irradiation.
+func dailyWeather(d day, options ET0Options) (meteo.DailyWeather, bool) {
+	weather := meteo.DailyWeather{Date: d.date}
+	for _, variable := range []string{"temperature", "humidity", "windSpeedInst", "solarIrradiation"} {
+		if !complete(d.hours, variable) {
+			return weather, false
+		}
+	}
+	var ok bool
+	if weather.TMin, weather.TMax, ok = extremes(d.hours, "temperature"); !ok {
+		return weather, false
+	}
+	if weather.RHMin, weather.RHMax, ok = extremes(d.hours, "humidity"); !ok {
+		return weather, false
+	}
+
+	var wind, pressure float64
+	var winds, pressures int
+	for _, hour := range d.hours {
+		if speed, found := hour.Variables["windSpeedInst"]; found {
+			wind += speed
+			winds++
+		}
+		if value, found := hour.Variables["atmosphericPressureInst"]; found {
+			pressure += value
+			pressures++
+		}
+		if irradiation, found := hour.Variables["solarIrradiation"]; found {
+			weather.SolarRadiation += irradiation * whToMJ
+		}
+	}
+	weather.WindSpeed = meteo.WindSpeedAt2m(wind/float64(winds), options.WindHeight)
+
+	station, known := options.Stations.Get(d.areaID)
+	switch {
+	case len(d.lonlat) == 2:
+		weather.Latitude = d.lonlat[1]
+	case known:
+		weather.Latitude = station.Lat
+	default:
+		return weather, false
+	}
+	// the elevation of the station or else of its pressure, in hPa
+	switch {
+	case known && station.Elevation != 0:
+		weather.Elevation = station.Elevation
+	case pressures > 0:
+		weather.Elevation = meteo.Elevation(pressure / float64(pressures) / 10)
+	}
+	return weather, true
+}
+
+// ReferenceEvapotranspiration accumulates the reference evapotranspiration of
+// the areas by day in loc. Days missing hours of one of its inputs are left
+// out.
+func (c *ClimateUsecase) ReferenceEvapotranspiration(ctx context.Context, table string, areas, dates []string, loc *time.Location, options ET0Options) (series []entity.AgroSeries, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.ReferenceEvapotranspiration")
+	defer func() { tracing.End(span, err) }()
+
+	return c.accumulate(ctx, table, areas, dates, loc, "et0", "mm", func(d day) (entity.AgroDay, bool) {
+		weather, ok := dailyWeather(d, options)
+		if !ok {
+			return entity.AgroDay{}, false
+		}
+		return entity.AgroDay{
+			Value:          meteo.ReferenceEvapotranspiration(weather),
+			TemperatureMin: &weather.TMin,
+			TemperatureMax: &weather.TMax,
+		}, true
+	})
+}
+
+// WaterBalance follows the water of the soil of the areas by day in loc, which
+// the rain fills up to its capacity and the reference evapotranspiration
+// empties. The rain of the days missing hours of an input of the
+// evapotranspiration still fills the soil, which nothing empties then, and
+// their evapotranspiration is left null.
+func (c *ClimateUsecase) WaterBalance(ctx context.Context, table string, areas, dates []string, loc *time.Location, options WaterBalanceOptions) (balances []entity.WaterBalance, err error) {
+	ctx, span := tracing.Start(ctx, "ClimateUsecase.WaterBalance")
+	defer func() { tracing.End(span, err) }()
+
+	byArea := make(map[string]*entity.WaterBalance, len(areas))
+	storage := make(map[string]float64, len(areas))
+	balances = make([]entity.WaterBalance, len(areas))
+	for i, area := range areas {
+		balances[i] = entity.WaterBalance{AreaID: area, Capacity: options.Capacity, Days: []entity.WaterBalanceDay{}}
+		byArea[area] = &balances[i]
+		storage[area] = options.Initial
+	}
+	err = c.days(ctx, table, areas, dates, loc, func(d day) bool {
+		balance, ok := byArea[d.areaID]
+		if !ok {
+			return true
+		}
+		var rain float64
+		for _, hour := range d.hours {
+			rain += hour.Variables["rain"]
+		}
+		rain = round(rain)
+		var et0 *float64
+		var evaporated float64
+		if weather, ok := dailyWeather(d, options.ET0Options); ok {
+			evaporated = round(meteo.ReferenceEvapotranspiration(weather))
+			et0 = &evaporated
+			balance.ET0 = round(balance.ET0 + evaporated)
+		}
+		water, drainage := meteo.WaterBalance(storage[d.areaID], options.Capacity, rain, evaporated)
+		storage[d.areaID] = water
+
+		balance.Days = append(balance.Days, entity.WaterBalanceDay{
+			Date:     d.date.Format("2006-01-02"),
+			Hours:    len(d.hours),
+			Rain:     rain,
+			ET0:      et0,
+			Storage:  round(water),
+			Drainage: round(drainage),
+			Deficit:  round(options.Capacity - water),
+		})
+		balance.Rain = round(balance.Rain + rain)
+		balance.Drainage = round(balance.Drainage + drainage)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"time"
+)
+
+// IssuedBefore wraps fn to keep, of the versions of each key, the newest one
+// created at least lead before the date of the key: the forecast of that lead
+// time. The versions of a key must be read newest first, as Bigtable does.
+func IssuedBefore(lead time.Duration, fn func(entity.BigtableOutput) bool) func(entity.BigtableOutput) bool {
+	selectedKey := ""
+	return func(output entity.BigtableOutput) bool {
+		if output.Key == selectedKey {
+			return true
+		}
+		key, err := entity.ParseKey(output.Key)
+		if err != nil || output.Created.After(key.Date.Add(-lead)) {
+			return true
+		}
+		selectedKey = output.Key
+		return fn(output)
+	}
+}
+
+// LeadTime returns the time between the creation of a cell and the date of
+// its key, and false when the key is malformed.
+func LeadTime(output entity.BigtableOutput) (time.Duration, bool) {
+	key, err := entity.ParseKey(output.Key)
+	if err != nil {
+		return 0, false
+	}
+	return key.Date.Sub(output.Created), true
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"bigtable_api/entity"
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SkillOptions are the options of the forecast verification.
+type SkillOptions struct {
+	// Variables are the verified variables, those of both payloads when empty.
+	Variables []string
+	// LeadTimeStep is the width of the lead time groups.
+	LeadTimeStep time.Duration
+	// RainThreshold is the amount of rain, in mm, from which it rains.
+	RainThreshold float64
+	// Versions is how many forecasts of each date are verified, each version
+	// being a forecast issued at another time.
+	Versions int
+}
+
+type skillGroup struct {
+	areaID   string
+	variable string
+	lead     int
+}
+
+// Skill verifies every version of the forecasts of the areas between the dates
+// against the observations, grouping the statistics by area, variable and lead
+// time, the time between the creation of a forecast and its date. Forecasts
+// created after their date are not verified.
+func (c *ClimateUsecase) Skill(ctx context.Context, table string, areas, dates []string, filters map[string]string, options SkillOptions) ([]entity.Skill, error) {
+	forecastFilters := map[string]string{"version": strconv.Itoa(options.Versions)}
+	for name, value := range filters {
+		forecastFilters[name] = value
+	}
+	step := int(options.LeadTimeStep / time.Hour)
+	skills := make(map[skillGroup]*entity.Skill)
+	sumAbs := make(map[skillGroup]float64)
+	sumSquares := make(map[skillGroup]float64)
+
+	err := c.Pair(ctx, table, areas, dates, forecastFilters, func(forecast, observed entity.BigtableOutput) bool {
+		key, err := entity.ParseKey(forecast.Key)
+		if err != nil {
+			return true
+		}
+		lead := key.Date.Sub(forecast.Created)
+		if lead < 0 {
+			return true
+		}
+		forecastPayload, err := entity.DecodePayload(forecast.Value)
+		if err != nil {
+			return true
+		}
+		observedPayload, err := entity.DecodePayload(observed.Value)
+		if err != nil {
+			return true
+		}
+		leadFrom := int(lead/time.Hour) / step * step
+		for _, name := range skillVariables(options.Variables, forecastPayload) {
+			forecastValue, ok := forecastPayload.Variables[name]
+			if !ok {
+				continue
+			}
+			observedValue, ok := observedPayload.Variables[name]
+			if !ok {
+				continue
+			}
+			group := skillGroup{areaID: key.AreaID, variable: name, lead: leadFrom}
+			skill, ok := skills[group]
+			if !ok {
+				skill = &entity.Skill{AreaID: key.AreaID, Variable: name, LeadTimeFrom: leadFrom, LeadTimeTo: leadFrom + step}
+				if name == "rain" {
+					skill.RainOccurrence = &entity.Contingency{}
+				}
+				skills[group] = skill
+			}
+			difference := forecastValue - observedValue
+			skill.Count++
+			skill.Bias += difference
+			sumAbs[group] += math.Abs(difference)
+			sumSquares[group] += difference * difference
+			if occurrence := skill.RainOccurrence; occurrence != nil {
+				forecastRain, observedRain := forecastValue >= options.RainThreshold, observedValue >= options.RainThreshold
+				switch {
+				case forecastRain && observedRain:
+					occurrence.Hits++
+				case observedRain:
+					occurrence.Misses++
+				case forecastRain:
+					occurrence.FalseAlarms++
+				default:
+					occurrence.CorrectNegatives++
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.Skill, 0, len(skills))
+	for group, skill := range skills {
+		n := float64(skill.Count)
+		skill.MAE = sumAbs[group] / n
+		skill.RMSE = math.Sqrt(sumSquares[group] / n)
+		skill.Bias /= n
+		if occurrence := skill.RainOccurrence; occurrence != nil && occurrence.Hits+occurrence.Misses > 0 {
+			hitRate := float64(occurrence.Hits) / float64(occurrence.Hits+occurrence.Misses)
+			occurrence.HitRate = &hitRate
+		}
+		result = append(result, *skill)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.AreaID != b.AreaID {
+			return a.AreaID < b.AreaID
+		}
+		if a.Variable != b.Variable {
+			return a.Variable < b.Variable
+		}
+		return a.LeadTimeFrom < b.LeadTimeFrom
+	})
+	return result, nil
+}
+
+// skillVariables returns the verified variables of a forecast.
+func skillVariables(variables []string, forecast entity.Payload) []string {
+	if len(variables) > 0 {
+		return variables
+	}
+	return forecast.VariableNames()
+}